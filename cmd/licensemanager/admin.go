@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/admin"
+	"github.com/Zeroshcat/LicenseManager/internal/auth"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
+)
+
+// runAdmin 后台管理
+// 用法：licensemanager admin <serve|token> [options]
+func runAdmin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: licensemanager admin <serve|token> [options]")
+	}
+
+	switch args[0] {
+	case "serve":
+		return runAdminServe(args[1:])
+	case "token":
+		return runAdminToken(args[1:])
+	default:
+		return fmt.Errorf("unknown admin command: %s (serve|token)", args[0])
+	}
+}
+
+// runAdminServe 启动Web管理服务器
+func runAdminServe(args []string) error {
+	fs, format := newFlagSet("admin serve")
+	password := fs.String("passwd", "", "管理密码（必须）")
+	host := fs.String("host", "localhost", "监听地址")
+	port := fs.Int("port", 8080, "监听端口")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	passphraseFile := fs.String("passphrase-file", "", "密钥环口令文件（默认读取环境变量 LICENSEMANAGER_PASSPHRASE 或终端输入）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *password == "" {
+		return fmt.Errorf("--passwd is required")
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// 启动时解锁一次密钥环，私钥只在进程内存中解密
+	ring, err := keyring.Open(db, *passphraseFile)
+	if err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+
+	webAdmin, err := admin.NewWebAdmin(db, ring, *password)
+	if err != nil {
+		return fmt.Errorf("failed to create web admin: %w", err)
+	}
+
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	if err := printResult(*format, map[string]interface{}{
+		"address": "http://" + addr,
+		"message": "Admin server started",
+	}); err != nil {
+		return err
+	}
+
+	return http.ListenAndServe(addr, webAdmin)
+}
+
+// runAdminToken Token管理
+// 用法：licensemanager admin token create --type client --app-id <app-id> [--expires 2024-12-31]
+func runAdminToken(args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return fmt.Errorf("usage: licensemanager admin token create --type <client|admin> [--app-id <app-id>] [--expires YYYY-MM-DD]")
+	}
+
+	fs, format := newFlagSet("admin token create")
+	tokenType := fs.String("type", "client", "Token类型（client|admin）")
+	appID := fs.String("app-id", "", "应用ID（client类型必须）")
+	expires := fs.String("expires", "", "过期日期（YYYY-MM-DD，默认永不过期）")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	if _, err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	switch *tokenType {
+	case "client":
+		if *appID == "" {
+			return fmt.Errorf("--app-id is required for client tokens")
+		}
+	case "admin":
+	default:
+		return fmt.Errorf("invalid token type: %s (client|admin)", *tokenType)
+	}
+
+	record := &database.TokenRecord{
+		TokenType: *tokenType,
+		AppID:     *appID,
+	}
+
+	if *expires != "" {
+		expiresAt, err := time.Parse("2006-01-02", *expires)
+		if err != nil {
+			return fmt.Errorf("invalid expires date format (use YYYY-MM-DD): %w", err)
+		}
+		record.ExpiresAt = &expiresAt
+	}
+
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+	record.Token = token
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.SaveToken(record); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	return printResult(*format, record)
+}
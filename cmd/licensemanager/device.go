@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/pkg/device"
+)
+
+// runDevice 设备管理
+// 用法：licensemanager device <list|show|bind|rekey|keygen> [options]
+func runDevice(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: licensemanager device <list|show|bind|rekey|keygen> [options]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runDeviceList(args[1:])
+	case "show":
+		return runDeviceShow(args[1:])
+	case "bind":
+		return runDeviceBind(args[1:])
+	case "rekey":
+		return runDeviceRekey(args[1:])
+	case "keygen":
+		return runDeviceKeygen(args[1:])
+	default:
+		return fmt.Errorf("unknown device command: %s (list|show|bind|rekey|keygen)", args[0])
+	}
+}
+
+// runDeviceList 列出所有设备
+func runDeviceList(args []string) error {
+	fs, format := newFlagSet("device list")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	limit := fs.Int("limit", 50, "最大返回数量")
+	offset := fs.Int("offset", 0, "偏移量")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	devices, err := db.ListDevices(*limit, *offset)
+	if err != nil {
+		return fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	return printResult(*format, devices)
+}
+
+// runDeviceShow 查看设备详情
+// 未指定设备ID时显示本机设备ID
+func runDeviceShow(args []string) error {
+	fs, format := newFlagSet("device show")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// 未指定设备ID，显示本机设备ID
+	if len(positional) == 0 {
+		deviceID, err := device.GetDeviceID()
+		if err != nil {
+			return err
+		}
+		return printResult(*format, map[string]interface{}{
+			"device_id": deviceID,
+		})
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	deviceRecord, err := db.GetDeviceByID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{
+		"device":  deviceRecord,
+		"license": nil,
+	}
+	if licenseRecord, err := db.GetLicenseByDeviceID(deviceRecord.DeviceID); err == nil {
+		result["license"] = licenseRecord
+	}
+
+	return printResult(*format, result)
+}
+
+// runDeviceBind 绑定（注册）设备
+func runDeviceBind(args []string) error {
+	fs, format := newFlagSet("device bind")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	name := fs.String("name", "", "设备名称")
+	appID := fs.String("app-id", "", "应用ID")
+	publicKey := fs.String("public-key", "", "设备公钥（base64，见 device keygen；设置后许可证加密到该公钥）")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: licensemanager device bind <device-id> [--name <name>] [--app-id <app-id>] [--public-key <base64>]")
+	}
+	if *publicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(*publicKey)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("invalid --public-key: must be a base64 encoded 32-byte X25519 public key")
+		}
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	record := &database.DeviceRecord{
+		DeviceID:   positional[0],
+		DeviceName: *name,
+		AppID:      *appID,
+		PublicKey:  *publicKey,
+		Status:     "active",
+	}
+
+	// 关联已有的许可证
+	if licenseRecord, err := db.GetLicenseByDeviceID(record.DeviceID); err == nil {
+		record.LicenseID = licenseRecord.ID
+	}
+
+	if _, err := db.SaveDevice(record); err != nil {
+		return fmt.Errorf("failed to bind device: %w", err)
+	}
+
+	return printResult(*format, record)
+}
+
+// runDeviceRekey 更换已注册设备的公钥（设备丢失 device_key.bin 后重新生成密钥对时使用）
+// 用法：licensemanager device rekey <device-id> --public-key <base64>
+func runDeviceRekey(args []string) error {
+	fs, format := newFlagSet("device rekey")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	publicKey := fs.String("public-key", "", "新的设备公钥（base64，见 device keygen；为空时清除公钥，之后签发的许可证不再加密到设备）")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: licensemanager device rekey <device-id> --public-key <base64>")
+	}
+	if *publicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(*publicKey)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("invalid --public-key: must be a base64 encoded 32-byte X25519 public key")
+		}
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.UpdateDevicePublicKey(positional[0], *publicKey); err != nil {
+		return fmt.Errorf("failed to rekey device: %w", err)
+	}
+
+	record, err := db.GetDeviceByID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	return printResult(*format, record)
+}
+
+// runDeviceKeygen 生成（或加载已有的）本机设备密钥对，并输出设备公钥
+// 私钥保存在本机，公钥通过 device bind --public-key 或设备注册接口提交给服务器
+func runDeviceKeygen(args []string) error {
+	fs, format := newFlagSet("device keygen")
+	output := fs.String("output", "device_key.bin", "设备私钥文件路径（已存在时直接加载）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	keyPair, err := device.LoadOrCreateKeyPair(*output)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := device.GetDeviceID()
+	if err != nil {
+		return err
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"device_id":  deviceID,
+		"public_key": keyPair.PublicKeyBase64(),
+		"key_file":   *output,
+	})
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// runGenerate 生成许可证
+// 用法：licensemanager generate --type offline --device-id <id> <--expiry 2024-12-31|--perpetual [--maintenance-until 2025-12-31]> [--not-before 2024-01-01] [--seats N] [--max-activations N] [--trial-days N] [--features a,b] [--entitlements export,max_users=50,tier=pro] [--product <id> [--version-range 2.x]] [--sign-only] [--output license.key]
+func runGenerate(args []string) error {
+	fs, format := newFlagSet("generate")
+	licType := fs.String("type", string(license.LicenseTypeOffline), "许可证类型（offline|online|dual|floating|trial）")
+	deviceID := fs.String("device-id", "", "设备ID（必须，浮动许可证为客户或席位池ID，试用许可证可省略）")
+	seats := fs.Int("seats", 0, "并发席位数（floating 类型必须）")
+	maxActivations := fs.Int("max-activations", 0, "最大激活设备数（大于0时为多设备许可证，--device-id 为客户ID，仅 online 和 dual 类型）")
+	trialDays := fs.Int("trial-days", 0, "试用天数（trial 类型必须，从设备首次激活开始计算，--expiry 为试用截止日期）")
+	expiry := fs.String("expiry", "", "到期日期（YYYY-MM-DD，永久许可证以外必须）")
+	notBefore := fs.String("not-before", "", "生效日期（YYYY-MM-DD，默认签发后立即生效）")
+	perpetual := fs.Bool("perpetual", false, "永久许可证（不过期，不能与 --expiry 同时使用）")
+	maintenanceUntil := fs.String("maintenance-until", "", "维护截止日期（YYYY-MM-DD，仅永久许可证，之后发布的版本不在授权范围内）")
+	features := fs.String("features", "", "功能列表（逗号分隔）")
+	entitlementList := fs.String("entitlements", "", "授权项（逗号分隔，name 为功能开关，name=<整数> 为数量限制，name=<字符串> 为等级等）")
+	product := fs.String("product", "", "产品或客户ID（写入许可证，加密时使用该产品的派生密钥）")
+	appID := fs.String("app-id", "", "所属应用ID（客户端Token只能访问所属应用的许可证）")
+	versionRange := fs.String("version-range", "", "适用的产品版本范围（例如 2.x 或 \">=2.1 <4\"，需要 --product）")
+	signOnly := fs.Bool("sign-only", false, "仅签名不加密（客户端只需公钥即可验证和查看许可证）")
+	outputPath := fs.String("output", "", "许可证输出文件")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	signerOpts := addSignerFlags(fs)
+	issuer := fs.String("issuer", license.DefaultIssuer, "签发者")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	lt, err := parseLicenseType(*licType)
+	if err != nil {
+		return err
+	}
+
+	// 试用许可证可以不绑定设备，由首次激活的设备使用
+	if *deviceID == "" && lt != license.LicenseTypeTrial {
+		return fmt.Errorf("--device-id is required")
+	}
+	if *expiry == "" && !*perpetual {
+		return fmt.Errorf("--expiry is required unless --perpetual is set")
+	}
+
+	expiryDate, err := parseDate(*expiry)
+	if err != nil {
+		return fmt.Errorf("invalid expiry date format (use YYYY-MM-DD): %w", err)
+	}
+	notBeforeDate, err := parseDate(*notBefore)
+	if err != nil {
+		return fmt.Errorf("invalid not-before date format (use YYYY-MM-DD): %w", err)
+	}
+	maintenanceDate, err := parseDate(*maintenanceUntil)
+	if err != nil {
+		return fmt.Errorf("invalid maintenance date format (use YYYY-MM-DD): %w", err)
+	}
+
+	entitlements, err := license.ParseEntitlements(splitList(*entitlementList))
+	if err != nil {
+		return err
+	}
+	entitlementsJSON, err := encodeEntitlements(entitlements)
+	if err != nil {
+		return err
+	}
+
+	// 打开数据库（签名密钥保存在密钥环中，许可证记录用于网络验证和双重验证）
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	signer, aesKey, err := loadSigner(db, signerOpts)
+	if err != nil {
+		return err
+	}
+	defer closeSigner(signer)
+
+	// 生成许可证
+	generator := licensegen.NewGenerator(signer, aesKey)
+	generator.SetIssuer(*issuer)
+	// 仅签名的许可证只在载荷中记录产品ID，不使用产品密钥
+	if !*signOnly {
+		generator.SetProduct(*product)
+	}
+	generator.SetSignatureOnly(*signOnly)
+	// 设备注册时提交了公钥的，许可证加密到该设备的公钥
+	generator.SetDeviceKeys(db.GetDevicePublicKey)
+	lic, licenseKey, err := generator.IssueWith(licensegen.IssueOptions{
+		DeviceID:         *deviceID,
+		ProductID:        *product,
+		VersionRange:     *versionRange,
+		LicenseType:      lt,
+		Seats:            *seats,
+		MaxActivations:   *maxActivations,
+		TrialDays:        *trialDays,
+		ExpiryDate:       expiryDate,
+		NotBefore:        notBeforeDate,
+		Perpetual:        *perpetual,
+		MaintenanceUntil: maintenanceDate,
+		Features:         splitList(*features),
+		Entitlements:     entitlements,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate license: %w", err)
+	}
+
+	// 保存到数据库（网络验证和双重验证依赖该记录）
+	record := &database.LicenseRecord{
+		LicenseID:        lic.ID,
+		DeviceID:         *deviceID,
+		ProductID:        *product,
+		AppID:            *appID,
+		VersionRange:     *versionRange,
+		LicenseKey:       licenseKey,
+		LicenseType:      string(lt),
+		Seats:            lic.Seats,
+		MaxActivations:   lic.MaxActivations,
+		TrialDays:        lic.TrialDays,
+		Features:         strings.Join(lic.Features, ","),
+		Entitlements:     entitlementsJSON,
+		ExpiryDate:       expiryDate,
+		NotBefore:        notBeforeDate,
+		Perpetual:        lic.Perpetual,
+		MaintenanceUntil: maintenanceDate,
+	}
+	if _, err := db.SaveLicense(record); err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{
+		"id":           record.ID,
+		"license_id":   lic.ID,
+		"issuer":       lic.Issuer,
+		"product_id":   *product,
+		"device_id":    *deviceID,
+		"license_type": string(lt),
+	}
+	if lic.Perpetual {
+		result["perpetual"] = true
+	} else {
+		result["expiry_date"] = expiryDate.Format("2006-01-02")
+	}
+	if !notBeforeDate.IsZero() {
+		result["not_before"] = notBeforeDate.Format("2006-01-02")
+	}
+	if !maintenanceDate.IsZero() {
+		result["maintenance_until"] = maintenanceDate.Format("2006-01-02")
+	}
+	if *versionRange != "" {
+		result["version_range"] = *versionRange
+	}
+	if lic.Seats > 0 {
+		result["seats"] = lic.Seats
+	}
+	if lic.IsMultiDevice() {
+		result["max_activations"] = lic.MaxActivations
+	}
+	if lic.IsTrial() {
+		result["trial_days"] = lic.TrialDays
+	}
+	if len(lic.Features) > 0 {
+		result["features"] = lic.Features
+	}
+	if len(entitlements) > 0 {
+		result["entitlements"] = entitlements
+	}
+
+	if *outputPath != "" {
+		if err := os.WriteFile(*outputPath, []byte(licenseKey), 0644); err != nil {
+			return fmt.Errorf("failed to write license file: %w", err)
+		}
+		result["output"] = *outputPath
+	} else {
+		result["license_key"] = licenseKey
+	}
+
+	return printResult(*format, result)
+}
+
+// parseLicenseType 解析许可证类型
+func parseLicenseType(value string) (license.LicenseType, error) {
+	switch license.LicenseType(value) {
+	case license.LicenseTypeOffline, license.LicenseTypeOnline, license.LicenseTypeDual, license.LicenseTypeFloating, license.LicenseTypeTrial:
+		return license.LicenseType(value), nil
+	default:
+		return "", fmt.Errorf("invalid license type: %s (offline|online|dual|floating|trial)", value)
+	}
+}
+
+// parseDate 解析 YYYY-MM-DD 格式的日期，空字符串返回零值
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// encodeEntitlements 将授权项编码为JSON（保存到许可证记录，没有授权项时返回空字符串）
+func encodeEntitlements(entitlements license.Entitlements) (string, error) {
+	if len(entitlements) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(entitlements)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode entitlements: %w", err)
+	}
+	return string(data), nil
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
+)
+
+// runInit 初始化数据库、密钥环口令并生成密钥
+// 私钥和AES密钥加密保存在数据库的密钥环中，不写入磁盘；
+// 密钥目录只写入需要分发给客户端的公钥，客户端需要的AES密钥通过 key export-aes 显式导出
+// 用法：licensemanager init [--db license.db] [--keys .] [--algorithm rsa|ed25519] [--passphrase-file file] [--force]
+func runInit(args []string) error {
+	fs, format := newFlagSet("init")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	keysDir := fs.String("keys", defaultKeysDir, "密钥文件目录")
+	algorithmName := fs.String("algorithm", string(crypto.AlgorithmRSA), "签名算法（rsa|ed25519，ed25519 生成的许可证更短）")
+	passphraseFile := fs.String("passphrase-file", "", "密钥环口令文件（默认读取环境变量 LICENSEMANAGER_PASSPHRASE 或终端输入）")
+	force := fs.Bool("force", false, "重新生成已初始化的密钥（旧许可证将无法验证）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	algorithm, err := crypto.ParseAlgorithm(*algorithmName)
+	if err != nil {
+		return err
+	}
+
+	// 初始化数据库
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	// 防止意外覆盖已有密钥
+	initialized, err := keyring.New(db).Initialized()
+	if err != nil {
+		return err
+	}
+	publicPath := filepath.Join(*keysDir, publicKeyFile)
+	if !*force {
+		if initialized {
+			return fmt.Errorf("%w: %s (use --force to generate new keys)", keyring.ErrInitialized, *dbPath)
+		}
+		if _, err := os.Stat(publicPath); err == nil {
+			return fmt.Errorf("key file already exists: %s (use --force to overwrite)", publicPath)
+		}
+	}
+
+	// 首次初始化时设置密钥环口令，重新初始化时使用已有口令解锁
+	var ring *keyring.Keyring
+	if initialized {
+		ring, err = keyring.Open(db, *passphraseFile)
+	} else {
+		ring, err = keyring.Create(db, *passphraseFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+
+	if err := os.MkdirAll(*keysDir, 0755); err != nil {
+		return fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	// 生成签名密钥对
+	privateKeyPEM, publicKeyPEM, err := crypto.GenerateKeyPair(algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to generate %s key pair: %w", algorithm, err)
+	}
+
+	// 生成AES密钥
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		return fmt.Errorf("failed to generate AES key: %w", err)
+	}
+
+	// 将签名密钥设为密钥环中的激活密钥（重新初始化时旧密钥标记为退役）
+	record, err := ring.Import(privateKeyPEM)
+	if err != nil {
+		return err
+	}
+	if err := ring.ImportAESKey(aesKey); err != nil {
+		return err
+	}
+
+	// 写入客户端需要的公钥
+	if err := os.WriteFile(publicPath, publicKeyPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"database":   *dbPath,
+		"algorithm":  string(algorithm),
+		"key_id":     record.KeyID,
+		"public_key": publicPath,
+		"message":    "Initialized successfully, the private key and AES key are stored encrypted in the database keyring (use 'key export-aes' to export the AES key for clients)",
+	})
+}
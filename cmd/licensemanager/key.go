@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
+)
+
+// runKey 签名密钥管理
+// 用法：licensemanager key <init|list|rotate|export|export-aes> [options]
+func runKey(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: licensemanager key <init|list|rotate|export|export-aes> [options]")
+	}
+
+	switch args[0] {
+	case "init":
+		return runKeyInit(args[1:])
+	case "list":
+		return runKeyList(args[1:])
+	case "rotate":
+		return runKeyRotate(args[1:])
+	case "export":
+		return runKeyExport(args[1:])
+	case "export-aes":
+		return runKeyExportAES(args[1:])
+	default:
+		return fmt.Errorf("unknown key command: %s (init|list|rotate|export|export-aes)", args[0])
+	}
+}
+
+// runKeyInit 为已有数据库设置密钥环口令
+// 用于密钥环引入之前初始化的数据库：设置口令后加密数据库中以明文保存的密钥，
+// 并在密钥环中没有对应密钥时导入密钥目录中的 private_key.pem 和 aes_key.bin，导入后删除这两个明文文件；
+// 密钥环中已有对应密钥而没有导入的文件不会删除，输出警告
+// 用法：licensemanager key init [--keys .] [--passphrase-file file]
+func runKeyInit(args []string) error {
+	fs, format := newFlagSet("key init")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	keysDir := fs.String("keys", defaultKeysDir, "旧版本密钥文件目录")
+	passphraseFile := fs.String("passphrase-file", "", "密钥环口令文件（默认读取环境变量 LICENSEMANAGER_PASSPHRASE 或终端输入）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	ring, err := keyring.Create(db, *passphraseFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize keyring: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"database": *dbPath,
+		"message":  "Keyring initialized",
+	}
+
+	// 导入旧版本生成的密钥文件，文件不存在时跳过
+	privatePath := filepath.Join(*keysDir, privateKeyFile)
+	if _, err := ring.LoadSigner(privatePath); err == nil {
+		_, record, err := ring.Signer()
+		if err != nil {
+			return err
+		}
+		result["key_id"] = record.KeyID
+	} else if !errors.Is(err, keyring.ErrNoActiveKey) {
+		return fmt.Errorf("failed to import %s: %w", privatePath, err)
+	}
+
+	aesPath := filepath.Join(*keysDir, aesKeyFile)
+	if _, err := ring.LoadAESKey(aesPath); err == nil {
+		result["aes_key"] = "imported"
+	} else if !errors.Is(err, keyring.ErrNoAESKey) {
+		return fmt.Errorf("failed to import %s: %w", aesPath, err)
+	}
+
+	// 导入的文件已经删除，仍然存在的文件没有导入（密钥环中已有密钥），其中的明文密钥需要手动处理
+	var remaining []string
+	for _, path := range []string{privatePath, aesPath} {
+		if _, err := os.Stat(path); err == nil {
+			remaining = append(remaining, path)
+		}
+	}
+	if len(remaining) > 0 {
+		result["warning"] = fmt.Sprintf("plaintext key files were not imported because the keyring already has keys; verify and delete them: %s", strings.Join(remaining, ", "))
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", result["warning"])
+	}
+
+	return printResult(*format, result)
+}
+
+// runKeyList 列出密钥环中的签名密钥
+func runKeyList(args []string) error {
+	fs, format := newFlagSet("key list")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	records, err := db.ListKeys(database.KeyTypeSigning)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	// 只输出公开信息（文本格式会打印结构体的所有字段，包括私钥数据）
+	keys := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, map[string]interface{}{
+			"key_id":     record.KeyID,
+			"algorithm":  record.Algorithm,
+			"status":     record.Status,
+			"created_at": record.CreatedAt,
+			"retired_at": record.RetiredAt,
+		})
+	}
+
+	return printResult(*format, keys)
+}
+
+// runKeyRotate 轮换签名密钥
+// 新密钥成为激活密钥，旧密钥标记为退役（仍可验证已签发的许可证），
+// 并将全部公钥写入密钥目录的 public_key.pem 供客户端更新
+// 用法：licensemanager key rotate [--algorithm rsa|ed25519] [--passphrase-file file]
+func runKeyRotate(args []string) error {
+	fs, format := newFlagSet("key rotate")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	keysDir := fs.String("keys", defaultKeysDir, "密钥文件目录")
+	algorithmName := fs.String("algorithm", string(crypto.AlgorithmRSA), "新密钥的签名算法（rsa|ed25519）")
+	passphraseFile := fs.String("passphrase-file", "", "密钥环口令文件（默认读取环境变量 LICENSEMANAGER_PASSPHRASE 或终端输入）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	algorithm, err := crypto.ParseAlgorithm(*algorithmName)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	ring, err := keyring.Open(db, *passphraseFile)
+	if err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+
+	record, err := ring.Rotate(algorithm)
+	if err != nil {
+		return err
+	}
+
+	publicPath := filepath.Join(*keysDir, publicKeyFile)
+	if err := writePublicKeys(ring, publicPath); err != nil {
+		return err
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"key_id":     record.KeyID,
+		"algorithm":  record.Algorithm,
+		"public_key": publicPath,
+		"message":    "Signing key rotated, distribute the updated public key file to clients",
+	})
+}
+
+// runKeyExport 导出全部可用于验证的公钥（激活 + 已退役）
+// 用法：licensemanager key export [--output public_key.pem]
+func runKeyExport(args []string) error {
+	fs, format := newFlagSet("key export")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	outputPath := fs.String("output", publicKeyFile, "公钥输出文件")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := writePublicKeys(keyring.New(db), *outputPath); err != nil {
+		return err
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"output":  *outputPath,
+		"message": "Public keys exported, distribute the file to clients",
+	})
+}
+
+// runKeyExportAES 导出分发给客户端的AES密钥
+// 指定产品时导出从主AES密钥派生的产品密钥，客户端只能解密该产品的许可证
+// 用法：licensemanager key export-aes [--product <id>] [--output aes_key_<id>.bin]
+func runKeyExportAES(args []string) error {
+	fs, format := newFlagSet("key export-aes")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	product := fs.String("product", "", "产品或客户ID（为空时导出主AES密钥）")
+	outputPath := fs.String("output", "", "AES密钥输出文件（默认 aes_key_<product>.bin，未指定产品时为 aes_key.bin）")
+	passphraseFile := fs.String("passphrase-file", "", "密钥环口令文件（默认读取环境变量 LICENSEMANAGER_PASSPHRASE 或终端输入）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// 默认文件名包含产品ID，避免覆盖密钥目录中的主AES密钥；产品ID不能包含路径分隔符
+	if *outputPath == "" {
+		*outputPath = aesKeyFile
+		if *product != "" {
+			if strings.ContainsAny(*product, `/\`) {
+				return fmt.Errorf("invalid product ID %q: must not contain path separators (use --output)", *product)
+			}
+			*outputPath = "aes_key_" + *product + ".bin"
+		}
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	ring, err := keyring.Open(db, *passphraseFile)
+	if err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+
+	aesKey, err := ring.AESKey()
+	if err != nil {
+		return fmt.Errorf("failed to load AES key: %w", err)
+	}
+
+	if *product != "" {
+		aesKey, err = crypto.DeriveProductKey(aesKey, *product)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(*outputPath, aesKey, 0600); err != nil {
+		return fmt.Errorf("failed to write AES key: %w", err)
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"product_id": *product,
+		"output":     *outputPath,
+		"message":    "AES key exported, keep the file private to the clients that need it",
+	})
+}
+
+// writePublicKeys 将密钥环中的全部公钥写入文件
+func writePublicKeys(ring *keyring.Keyring, path string) error {
+	bundle, err := ring.PublicKeys()
+	if err != nil {
+		return fmt.Errorf("failed to export public keys: %w", err)
+	}
+	if len(bundle) == 0 {
+		return keyring.ErrNoActiveKey
+	}
+
+	if err := os.WriteFile(path, bundle, 0644); err != nil {
+		return fmt.Errorf("failed to write public keys: %w", err)
+	}
+	return nil
+}
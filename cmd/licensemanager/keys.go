@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/hsm"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
+)
+
+// 密钥文件名
+const (
+	privateKeyFile = "private_key.pem" // 签名私钥（旧版本 init 生成，导入密钥环后可删除）
+	publicKeyFile  = "public_key.pem"  // 签名公钥
+	aesKeyFile     = "aes_key.bin"     // AES密钥
+)
+
+// signerFlags 签名密钥来源参数
+type signerFlags struct {
+	passphraseFile *string    // 密钥环口令文件
+	pkcs11         hsm.Config // PKCS#11 签名设备（模块路径为空时使用密钥环中的私钥）
+}
+
+// addSignerFlags 为签发类子命令添加密钥环口令和 PKCS#11 参数
+func addSignerFlags(fs *flag.FlagSet) *signerFlags {
+	flags := &signerFlags{
+		passphraseFile: fs.String("passphrase-file", "", "密钥环口令文件（默认读取环境变量 LICENSEMANAGER_PASSPHRASE 或终端输入）"),
+	}
+	fs.StringVar(&flags.pkcs11.Module, "pkcs11-module", "", "PKCS#11 模块路径（设置后使用HSM中的私钥签名）")
+	fs.StringVar(&flags.pkcs11.TokenLabel, "pkcs11-token", "", "PKCS#11 令牌标签")
+	fs.StringVar(&flags.pkcs11.KeyLabel, "pkcs11-key", "", "PKCS#11 签名密钥标签")
+	fs.StringVar(&flags.pkcs11.PINFile, "pkcs11-pin-file", "", "PKCS#11 用户PIN文件（默认读取环境变量 LICENSEMANAGER_PKCS11_PIN）")
+	return flags
+}
+
+// loadSigner 解锁密钥环，加载签名密钥和AES密钥
+// 配置了 PKCS#11 设备时使用设备中的私钥签名，并将其公钥登记到密钥环；
+// 否则使用密钥环中的激活密钥（旧版本初始化的密钥目录需要先运行 key init 导入）
+// 参数：
+//   - db: 数据库连接
+//   - flags: 签名密钥来源参数
+//
+// 返回值：
+//   - crypto.Signer: 签名器（算法由私钥类型决定，使用完毕后调用 closeSigner）
+//   - []byte: AES密钥
+//   - error: 加载过程中的错误
+func loadSigner(db *database.DB, flags *signerFlags) (crypto.Signer, []byte, error) {
+	ring, err := keyring.Open(db, *flags.passphraseFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+
+	var signer crypto.Signer
+	if flags.pkcs11.Enabled() {
+		signer, err = ring.OpenHSM(flags.pkcs11)
+	} else {
+		signer, _, err = ring.Signer()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load signing key: %w", err)
+	}
+
+	aesKey, err := ring.AESKey()
+	if err != nil {
+		closeSigner(signer)
+		return nil, nil, fmt.Errorf("failed to load AES key: %w", err)
+	}
+
+	return signer, aesKey, nil
+}
+
+// closeSigner 释放签名器持有的资源（例如 PKCS#11 会话）
+func closeSigner(signer crypto.Signer) {
+	if closer, ok := signer.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// loadPublicKey 从密钥目录加载签名公钥（PEM）和AES密钥
+// 参数：
+//   - dir: 密钥目录
+//
+// 返回值：
+//   - []byte: PEM编码的公钥
+//   - []byte: AES密钥
+//   - error: 加载过程中的错误
+func loadPublicKey(dir string) ([]byte, []byte, error) {
+	publicKeyPEM, err := readPublicKey(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aesKey, err := loadAESKey(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return publicKeyPEM, aesKey, nil
+}
+
+// readPublicKey 从密钥目录读取签名公钥（PEM）
+func readPublicKey(dir string) ([]byte, error) {
+	publicKeyPEM, err := os.ReadFile(filepath.Join(dir, publicKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	return publicKeyPEM, nil
+}
+
+// loadAESKey 从密钥目录加载AES密钥
+func loadAESKey(dir string) ([]byte, error) {
+	aesKey, err := os.ReadFile(filepath.Join(dir, aesKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AES key: %w", err)
+	}
+	if len(aesKey) != 32 {
+		return nil, fmt.Errorf("AES key must be 32 bytes, got %d bytes", len(aesKey))
+	}
+	return aesKey, nil
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// runLicense 许可证管理
+// 用法：licensemanager license <list|revoke|unrevoke|seats|activations|deactivate|crl> [options]
+func runLicense(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: licensemanager license <list|revoke|unrevoke|seats|activations|deactivate|crl> [options]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runLicenseList(args[1:])
+	case "revoke":
+		return runLicenseRevoke(args[1:])
+	case "unrevoke":
+		return runLicenseUnrevoke(args[1:])
+	case "seats":
+		return runLicenseSeats(args[1:])
+	case "activations":
+		return runLicenseActivations(args[1:])
+	case "deactivate":
+		return runLicenseDeactivate(args[1:])
+	case "crl":
+		return runLicenseCRL(args[1:])
+	default:
+		return fmt.Errorf("unknown license command: %s (list|revoke|unrevoke|seats|activations|deactivate|crl)", args[0])
+	}
+}
+
+// runLicenseList 列出所有许可证
+func runLicenseList(args []string) error {
+	fs, format := newFlagSet("license list")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	limit := fs.Int("limit", 50, "最大返回数量")
+	offset := fs.Int("offset", 0, "偏移量")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	licenses, err := db.ListLicenses(*limit, *offset)
+	if err != nil {
+		return fmt.Errorf("failed to list licenses: %w", err)
+	}
+
+	return printResult(*format, licenses)
+}
+
+// runLicenseRevoke 撤销许可证
+// 用法：licensemanager license revoke <license-id> [--reason <reason>] [--by <operator>]
+func runLicenseRevoke(args []string) error {
+	fs, format := newFlagSet("license revoke")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	reason := fs.String("reason", "", "撤销原因")
+	revokedBy := fs.String("by", "cli", "撤销操作人")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseLicenseIDArg(positional, "licensemanager license revoke <license-id> [--reason <reason>] [--by <operator>]")
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.RevokeLicense(id, *reason, *revokedBy); err != nil {
+		return err
+	}
+
+	record, err := db.GetLicenseByID(id)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*format, record)
+}
+
+// runLicenseUnrevoke 恢复已撤销的许可证
+// 用法：licensemanager license unrevoke <license-id>
+func runLicenseUnrevoke(args []string) error {
+	fs, format := newFlagSet("license unrevoke")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseLicenseIDArg(positional, "licensemanager license unrevoke <license-id>")
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.UnrevokeLicense(id); err != nil {
+		return err
+	}
+
+	record, err := db.GetLicenseByID(id)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*format, record)
+}
+
+// runLicenseSeats 列出浮动许可证当前被占用的席位
+// 用法：licensemanager license seats <license-id>
+func runLicenseSeats(args []string) error {
+	fs, format := newFlagSet("license seats")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseLicenseIDArg(positional, "licensemanager license seats <license-id>")
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	record, err := db.GetLicenseByID(id)
+	if err != nil {
+		return err
+	}
+	if record.LicenseType != string(license.LicenseTypeFloating) {
+		return fmt.Errorf("license %d is not a floating license", id)
+	}
+
+	leases, err := db.ListSeatLeases(record.LicenseID)
+	if err != nil {
+		return fmt.Errorf("failed to list seat leases: %w", err)
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"license_id": record.LicenseID,
+		"seats":      record.Seats,
+		"in_use":     len(leases),
+		"leases":     leases,
+	})
+}
+
+// runLicenseActivations 列出多设备许可证已激活的设备
+// 用法：licensemanager license activations <license-id>
+func runLicenseActivations(args []string) error {
+	fs, format := newFlagSet("license activations")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseLicenseIDArg(positional, "licensemanager license activations <license-id>")
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	record, err := db.GetLicenseByID(id)
+	if err != nil {
+		return err
+	}
+
+	activations, err := db.ListActivations(record.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list activations: %w", err)
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"license_id":      record.LicenseID,
+		"max_activations": record.MaxActivations,
+		"activated":       len(activations),
+		"activations":     activations,
+	})
+}
+
+// runLicenseDeactivate 停用多设备许可证上的设备，释放激活名额
+// 用法：licensemanager license deactivate <license-id> <device-id>
+func runLicenseDeactivate(args []string) error {
+	fs, _ := newFlagSet("license deactivate")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	const usage = "licensemanager license deactivate <license-id> <device-id>"
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: %s", usage)
+	}
+	id, err := parseLicenseIDArg(positional[:1], usage)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.DeactivateDevice(id, positional[1]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Device %s deactivated for license %d\n", positional[1], id)
+	return nil
+}
+
+// runLicenseCRL 导出已签名的吊销列表（供离线客户端使用）
+// 用法：licensemanager license crl [--output crl.json] [--validity 168h]
+func runLicenseCRL(args []string) error {
+	fs, format := newFlagSet("license crl")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	signerOpts := addSignerFlags(fs)
+	outputFile := fs.String("output", "crl.json", "吊销列表输出文件")
+	validity := fs.Duration("validity", 7*24*time.Hour, "吊销列表有效期")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *validity <= 0 {
+		return fmt.Errorf("--validity must be positive")
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	signer, _, err := loadSigner(db, signerOpts)
+	if err != nil {
+		return err
+	}
+	defer closeSigner(signer)
+
+	records, err := db.ListRevokedLicenses()
+	if err != nil {
+		return fmt.Errorf("failed to list revoked licenses: %w", err)
+	}
+
+	entries := licensegen.CRLEntriesFromRecords(records)
+	data, err := licensegen.GenerateCRL(entries, *validity, signer)
+	if err != nil {
+		return fmt.Errorf("failed to generate CRL: %w", err)
+	}
+
+	if err := os.WriteFile(*outputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write CRL: %w", err)
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"revoked": len(entries),
+		"output":  *outputFile,
+		"message": "CRL written, distribute it to offline clients before the next update",
+	})
+}
+
+// parseLicenseIDArg 解析位置参数中的许可证ID
+func parseLicenseIDArg(positional []string, usage string) (int64, error) {
+	if len(positional) != 1 {
+		return 0, fmt.Errorf("usage: %s", usage)
+	}
+
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid license ID: %s", positional[0])
+	}
+
+	return id, nil
+}
@@ -0,0 +1,134 @@
+// Package main 实现 licensemanager 统一命令行工具
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Zeroshcat/LicenseManager/pkg/output"
+)
+
+// 默认路径
+const (
+	defaultDBPath  = "license.db" // 默认数据库文件
+	defaultKeysDir = "."          // 默认密钥目录
+)
+
+// command 子命令定义
+type command struct {
+	name    string                    // 命令名称
+	summary string                    // 命令说明
+	run     func(args []string) error // 执行函数
+}
+
+// commands 返回所有顶级子命令
+func commands() []command {
+	return []command{
+		{name: "init", summary: "初始化数据库并生成密钥", run: runInit},
+		{name: "generate", summary: "生成许可证", run: runGenerate},
+		{name: "verify", summary: "验证许可证", run: runVerify},
+		{name: "license", summary: "许可证管理（list|revoke|unrevoke|seats|activations|deactivate|crl）", run: runLicense},
+		{name: "device", summary: "设备管理（list|show|bind|rekey|keygen）", run: runDevice},
+		{name: "renewal", summary: "离线续期（request|issue|install）", run: runRenewal},
+		{name: "key", summary: "密钥管理（init|list|rotate|export|export-aes）", run: runKey},
+		{name: "admin", summary: "后台管理（serve|token）", run: runAdmin},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			err := cmd.run(os.Args[2:])
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+// printUsage 打印顶级帮助信息
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: licensemanager <command> [options]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Run 'licensemanager <command> -h' for command options.")
+}
+
+// newFlagSet 创建带有 --format 选项的子命令参数集
+// 参数：
+//   - name: 子命令名称
+//
+// 返回值：
+//   - *flag.FlagSet: 参数集
+//   - *string: 输出格式（text|json）
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := fs.String("format", string(output.FormatText), "输出格式（text|json）")
+	return fs, format
+}
+
+// printResult 按指定格式输出结果
+// 参数：
+//   - format: 输出格式
+//   - data: 要输出的数据
+//
+// 返回值：
+//   - error: 输出过程中的错误
+func printResult(format string, data interface{}) error {
+	switch output.Format(format) {
+	case output.FormatText, output.FormatJSON:
+	default:
+		return fmt.Errorf("unsupported format: %s (text|json)", format)
+	}
+	return output.GetFormatter(output.Format(format)).Print(data)
+}
+
+// parseFlags 解析参数，允许选项出现在位置参数之后
+// 例如：device show <device-id> --format json
+// 参数：
+//   - fs: 参数集
+//   - args: 命令行参数
+//
+// 返回值：
+//   - []string: 位置参数
+//   - error: 解析过程中的错误
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
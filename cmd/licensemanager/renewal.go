@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
+	"github.com/Zeroshcat/LicenseManager/pkg/device"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// runRenewal 离线续期（无法访问授权服务器的客户端）
+// 用法：licensemanager renewal <request|issue|install> [options]
+func runRenewal(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: licensemanager renewal <request|issue|install> [options]")
+	}
+
+	switch args[0] {
+	case "request":
+		return runRenewalRequest(args[1:])
+	case "issue":
+		return runRenewalIssue(args[1:])
+	case "install":
+		return runRenewalInstall(args[1:])
+	default:
+		return fmt.Errorf("unknown renewal command: %s (request|issue|install)", args[0])
+	}
+}
+
+// runRenewalRequest 在客户端生成续期请求文件
+// 用法：licensemanager renewal request --state-dir <dir> [--license-file license.key] [--device-key device_key.bin] [--output renewal-request.json]
+func runRenewalRequest(args []string) error {
+	fs, format := newFlagSet("renewal request")
+	licenseFile := fs.String("license-file", "license.key", "当前许可证文件（读取许可证ID）")
+	licenseID := fs.String("license-id", "", "当前许可证ID（默认从 --license-file 读取）")
+	deviceID := fs.String("device-id", "", "设备ID（默认自动获取本机设备ID）")
+	deviceKey := fs.String("device-key", "device_key.bin", "设备私钥文件（响应许可证加密到该设备密钥，见 device keygen）")
+	stateDir := fs.String("state-dir", "", "客户端状态目录（必须，记录待处理的请求）")
+	keysDir := fs.String("keys", defaultKeysDir, "密钥文件目录")
+	product := fs.String("product", "", "产品ID（密钥目录中的 aes_key.bin 为该产品的密钥）")
+	outputFile := fs.String("output", "renewal-request.json", "请求文件输出路径")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	verifier, id, err := newRenewalVerifier(*keysDir, *product, *deviceKey, *stateDir, *deviceID)
+	if err != nil {
+		return err
+	}
+
+	if *licenseID == "" {
+		licenseKey, err := license.LoadLicenseFromFile(*licenseFile)
+		if err != nil {
+			return fmt.Errorf("failed to load license file %s: %w", *licenseFile, err)
+		}
+		lic, err := verifier.DecodeLicense(licenseKey)
+		if err != nil {
+			return fmt.Errorf("failed to decode license: %w", err)
+		}
+		*licenseID = lic.ID
+	}
+
+	data, err := verifier.CreateRenewalRequest(id, *licenseID)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*outputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write renewal request: %w", err)
+	}
+
+	return printResult(*format, map[string]interface{}{
+		"device_id":  id,
+		"license_id": *licenseID,
+		"output":     *outputFile,
+	})
+}
+
+// runRenewalIssue 在服务器端处理续期请求，签发响应许可证文件
+// 用法：licensemanager renewal issue --request renewal-request.json [--expiry 2025-12-31] [--output renewal-response.key]
+func runRenewalIssue(args []string) error {
+	fs, format := newFlagSet("renewal issue")
+	requestFile := fs.String("request", "renewal-request.json", "续期请求文件")
+	expiry := fs.String("expiry", "", "新的到期日期（YYYY-MM-DD，默认沿用原许可证的到期日期）")
+	outputFile := fs.String("output", "renewal-response.key", "响应许可证输出文件")
+	dbPath := fs.String("db", defaultDBPath, "数据库文件路径")
+	signerOpts := addSignerFlags(fs)
+	issuer := fs.String("issuer", license.DefaultIssuer, "签发者")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	expiryDate, err := parseDate(*expiry)
+	if err != nil {
+		return fmt.Errorf("invalid expiry date format (use YYYY-MM-DD): %w", err)
+	}
+
+	data, err := os.ReadFile(*requestFile)
+	if err != nil {
+		return fmt.Errorf("failed to read renewal request: %w", err)
+	}
+	req, err := license.ParseRenewalRequest(data)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	signer, aesKey, err := loadSigner(db, signerOpts)
+	if err != nil {
+		return err
+	}
+	defer closeSigner(signer)
+
+	generator := licensegen.NewGenerator(signer, aesKey)
+	generator.SetIssuer(*issuer)
+	record, err := licensegen.IssueRenewal(db, generator, req, expiryDate)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*outputFile, []byte(record.LicenseKey), 0644); err != nil {
+		return fmt.Errorf("failed to write license file: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":                 record.ID,
+		"license_id":         record.LicenseID,
+		"renewed_license_id": req.LicenseID,
+		"device_id":          record.DeviceID,
+		"output":             *outputFile,
+	}
+	if record.Perpetual {
+		result["perpetual"] = true
+	} else {
+		result["expiry_date"] = record.ExpiryDate.Format("2006-01-02")
+	}
+
+	return printResult(*format, result)
+}
+
+// runRenewalInstall 在客户端安装响应许可证（必须响应本设备待处理的请求）
+// 用法：licensemanager renewal install --response renewal-response.key --state-dir <dir> [--license-file license.key] [--device-key device_key.bin]
+func runRenewalInstall(args []string) error {
+	fs, format := newFlagSet("renewal install")
+	responseFile := fs.String("response", "renewal-response.key", "响应许可证文件")
+	licenseFile := fs.String("license-file", "license.key", "许可证文件路径（安装后替换）")
+	deviceID := fs.String("device-id", "", "设备ID（默认自动获取本机设备ID）")
+	deviceKey := fs.String("device-key", "device_key.bin", "设备私钥文件（见 device keygen）")
+	stateDir := fs.String("state-dir", "", "客户端状态目录（必须，与生成请求时一致）")
+	keysDir := fs.String("keys", defaultKeysDir, "密钥文件目录")
+	product := fs.String("product", "", "产品ID（密钥目录中的 aes_key.bin 为该产品的密钥）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	verifier, id, err := newRenewalVerifier(*keysDir, *product, *deviceKey, *stateDir, *deviceID)
+	if err != nil {
+		return err
+	}
+
+	responseKey, err := license.LoadLicenseFromFile(*responseFile)
+	if err != nil {
+		return fmt.Errorf("failed to load response file %s: %w", *responseFile, err)
+	}
+
+	result, err := verifier.InstallRenewal(responseKey, id, *licenseFile)
+	if result != nil {
+		if printErr := printResult(*format, result); printErr != nil {
+			return printErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to install renewal: %w", err)
+	}
+
+	return nil
+}
+
+// newRenewalVerifier 创建离线续期使用的验证器（设置设备私钥和状态目录）
+// 返回值：
+//   - *license.OfflineVerifier: 离线验证器
+//   - string: 设备ID（未指定时为本机设备ID）
+//   - error: 加载密钥失败时的错误
+func newRenewalVerifier(keysDir, product, deviceKeyPath, stateDir, deviceID string) (*license.OfflineVerifier, string, error) {
+	if stateDir == "" {
+		return nil, "", fmt.Errorf("--state-dir is required")
+	}
+
+	if deviceID == "" {
+		id, err := device.GetDeviceID()
+		if err != nil {
+			return nil, "", err
+		}
+		deviceID = id
+	}
+
+	publicKeyPEM, err := readPublicKey(keysDir)
+	if err != nil {
+		return nil, "", err
+	}
+	// 没有AES密钥文件时只能验证仅签名或加密到设备公钥的许可证
+	aesKey, err := loadAESKey(keysDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+
+	var verifier *license.OfflineVerifier
+	switch {
+	case aesKey == nil:
+		verifier, err = license.NewPublicVerifier(publicKeyPEM)
+	case product != "":
+		verifier, err = license.NewProductVerifier(publicKeyPEM, product, aesKey)
+	default:
+		verifier, err = license.NewOfflineVerifier(publicKeyPEM, aesKey)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	privateKey, err := os.ReadFile(deviceKeyPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read device key: %w", err)
+	}
+	if err := verifier.SetDeviceKey(privateKey); err != nil {
+		return nil, "", err
+	}
+	if err := verifier.SetStateDir(stateDir); err != nil {
+		return nil, "", err
+	}
+
+	return verifier, deviceID, nil
+}
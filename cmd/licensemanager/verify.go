@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Zeroshcat/LicenseManager/pkg/device"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// runVerify 验证许可证
+// 用法：
+//
+//	licensemanager verify --license-file license.key [--device-id <id>] [--product <id> [--app-version 2.3.1]] [--device-key device_key.bin] [--crl crl.json [--crl-strict]] [--state-dir <dir> [--clock-check]] [--grace-period 168h] [--warning-period 720h]
+//	licensemanager verify --online --device-id <id> --api-url http://localhost:8081 --token <token>
+//	licensemanager verify --dual --license-file license.key --device-id <id> --api-url http://localhost:8081 --token <token>
+func runVerify(args []string) error {
+	fs, format := newFlagSet("verify")
+	licenseFile := fs.String("license-file", "license.key", "许可证文件路径")
+	deviceID := fs.String("device-id", "", "设备ID（默认自动获取本机设备ID）")
+	online := fs.Bool("online", false, "网络验证")
+	dual := fs.Bool("dual", false, "双重验证（离线 + 网络）")
+	apiURL := fs.String("api-url", "", "授权服务器地址（网络验证和双重验证需要）")
+	appID := fs.String("app-id", "", "应用ID")
+	token := fs.String("token", "", "API Token（网络验证和双重验证需要）")
+	timeout := fs.Int("timeout", 10, "网络超时时间（秒）")
+	keysDir := fs.String("keys", defaultKeysDir, "密钥文件目录")
+	product := fs.String("product", "", "产品ID（只接受签发给该产品的许可证，密钥目录中的 aes_key.bin 为该产品的密钥）")
+	appVersion := fs.String("app-version", "", "当前产品版本（许可证限制了版本范围时需要）")
+	deviceKey := fs.String("device-key", "", "设备私钥文件（解密加密到本设备公钥的许可证，见 device keygen）")
+	crlFile := fs.String("crl", "", "吊销列表文件（离线验证时检查许可证是否已撤销）")
+	crlStrict := fs.Bool("crl-strict", false, "吊销列表过期时拒绝验证")
+	stateDir := fs.String("state-dir", "", "客户端状态目录（离线验证试用许可证时记录首次激活时间）")
+	clockCheck := fs.Bool("clock-check", false, "检测时钟回拨（需要 --state-dir；删除整个状态目录会重置检测，严格限制请使用网络验证）")
+	clockTolerance := fs.Duration("clock-tolerance", license.DefaultClockTolerance, "允许的时钟回退（例如 5m）")
+	gracePeriod := fs.Duration("grace-period", 0, "到期后的宽限期（例如 168h，宽限期内离线验证仍然成功）")
+	warningPeriod := fs.Duration("warning-period", 0, "到期前的提醒期（例如 720h，提醒期内返回 ExpiresSoon）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *online && *dual {
+		return fmt.Errorf("--online and --dual are mutually exclusive")
+	}
+	if (*online || *dual) && *apiURL == "" {
+		return fmt.Errorf("--api-url is required for online and dual verification")
+	}
+	if (*online || *dual) && *crlFile != "" {
+		return fmt.Errorf("--crl is only supported for offline verification")
+	}
+	if (*online || *dual) && *deviceKey != "" {
+		return fmt.Errorf("--device-key is only supported for offline verification")
+	}
+
+	// 未指定设备ID时自动获取本机设备ID
+	if *deviceID == "" {
+		id, err := device.GetDeviceID()
+		if err != nil {
+			return err
+		}
+		*deviceID = id
+	}
+
+	var result *license.VerifyResult
+	var err error
+
+	switch {
+	case *online:
+		verifier := license.NewOnlineVerifier(&license.OnlineConfig{
+			APIURL:  normalizeAPIURL(*apiURL),
+			AppID:   *appID,
+			Token:   *token,
+			Timeout: *timeout,
+		})
+		result, err = verifier.Verify(*deviceID)
+
+	case *dual:
+		publicKeyPEM, aesKey, loadErr := loadPublicKey(*keysDir)
+		if loadErr != nil {
+			return loadErr
+		}
+		licenseKey, loadErr := license.LoadLicenseFromFile(*licenseFile)
+		if loadErr != nil {
+			return fmt.Errorf("failed to load license file %s: %w", *licenseFile, loadErr)
+		}
+		verifier, newErr := license.NewDualVerifier(&license.DualConfig{
+			APIURL:         normalizeAPIURL(*apiURL),
+			AppID:          *appID,
+			Token:          *token,
+			Timeout:        *timeout,
+			ProductID:      *product,
+			ProductVersion: *appVersion,
+		}, publicKeyPEM, aesKey)
+		if newErr != nil {
+			return newErr
+		}
+		result, err = verifier.Verify(licenseKey, *deviceID)
+
+	default:
+		publicKeyPEM, loadErr := readPublicKey(*keysDir)
+		if loadErr != nil {
+			return loadErr
+		}
+		// 没有AES密钥文件时只能验证仅签名的许可证
+		aesKey, loadErr := loadAESKey(*keysDir)
+		if loadErr != nil && !errors.Is(loadErr, os.ErrNotExist) {
+			return loadErr
+		}
+		licenseKey, loadErr := license.LoadLicenseFromFile(*licenseFile)
+		if loadErr != nil {
+			return fmt.Errorf("failed to load license file %s: %w", *licenseFile, loadErr)
+		}
+		var verifier *license.OfflineVerifier
+		var newErr error
+		switch {
+		case aesKey == nil:
+			verifier, newErr = license.NewPublicVerifier(publicKeyPEM)
+		case *product != "":
+			verifier, newErr = license.NewProductVerifier(publicKeyPEM, *product, aesKey)
+		default:
+			verifier, newErr = license.NewOfflineVerifier(publicKeyPEM, aesKey)
+		}
+		if newErr != nil {
+			return newErr
+		}
+		if *product != "" {
+			if productErr := verifier.SetProduct(*product, *appVersion); productErr != nil {
+				return productErr
+			}
+		}
+		if *deviceKey != "" {
+			privateKey, readErr := os.ReadFile(*deviceKey)
+			if readErr != nil {
+				return fmt.Errorf("failed to read device key: %w", readErr)
+			}
+			if keyErr := verifier.SetDeviceKey(privateKey); keyErr != nil {
+				return keyErr
+			}
+		}
+		if *crlFile != "" {
+			if crlErr := verifier.LoadCRL(*crlFile, *crlStrict); crlErr != nil {
+				return crlErr
+			}
+		}
+		if *stateDir != "" {
+			if stateErr := verifier.SetStateDir(*stateDir); stateErr != nil {
+				return stateErr
+			}
+		}
+		policy := license.ExpiryPolicy{GracePeriod: *gracePeriod, WarningPeriod: *warningPeriod}
+		if policyErr := verifier.SetExpiryPolicy(policy); policyErr != nil {
+			return policyErr
+		}
+		if *clockCheck {
+			if clockErr := verifier.EnableClockCheck(*clockTolerance); clockErr != nil {
+				return fmt.Errorf("--clock-check requires --state-dir: %w", clockErr)
+			}
+		}
+		result, err = verifier.Verify(licenseKey, *deviceID)
+	}
+
+	// 即使验证失败也输出结果（例如已过期），便于诊断
+	if result != nil {
+		if printErr := printResult(*format, result); printErr != nil {
+			return printErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("license verification failed: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeAPIURL 规范化API地址
+// 允许传入服务器根地址（http://host:port），自动补全 /api/v1 前缀
+func normalizeAPIURL(apiURL string) string {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	if !strings.HasSuffix(apiURL, "/api/v1") {
+		apiURL += "/api/v1"
+	}
+	return apiURL
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/hsm"
+	"github.com/Zeroshcat/LicenseManager/internal/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 授权服务器配置
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`   // HTTP服务配置
+	Database DatabaseConfig `yaml:"database"` // 数据库配置
+	Keys     KeysConfig     `yaml:"keys"`     // 密钥配置
+	CRL      CRLConfig      `yaml:"crl"`      // 吊销列表配置
+	Seats    SeatsConfig    `yaml:"seats"`    // 浮动许可证席位配置
+	Expiry   ExpiryConfig   `yaml:"expiry"`   // 到期策略配置
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Host            string        `yaml:"host"`             // 监听地址
+	Port            int           `yaml:"port"`             // 监听端口
+	ReadTimeout     time.Duration `yaml:"read_timeout"`     // 读取请求超时
+	WriteTimeout    time.Duration `yaml:"write_timeout"`    // 写入响应超时
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`     // Keep-Alive空闲超时
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // 优雅关闭等待时间
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Path string `yaml:"path"` // 数据库文件路径
+}
+
+// KeysConfig 密钥配置
+// 双重验证需要服务器持有公钥和AES密钥以验证离线许可证：AES密钥保存在数据库的密钥环中，启动时使用口令解锁一次
+type KeysConfig struct {
+	PublicKey      string `yaml:"public_key"`      // 额外信任的公钥文件路径（可选，设置后必须可读）
+	PassphraseFile string `yaml:"passphrase_file"` // 密钥环口令文件（为空时读取环境变量 LICENSEMANAGER_PASSPHRASE）
+}
+
+// CRLConfig 吊销列表配置
+// 发布吊销列表需要签名私钥：密钥环中的激活密钥（使用 keys.passphrase_file 解锁）或 PKCS#11 设备中的密钥；
+// 未启用时 /api/v1/license/crl 返回 503
+type CRLConfig struct {
+	Enabled  bool          `yaml:"enabled"`  // 是否发布CRL
+	PKCS11   hsm.Config    `yaml:"pkcs11"`   // PKCS#11 签名设备（模块路径为空时使用密钥环）
+	Validity time.Duration `yaml:"validity"` // 吊销列表有效期
+}
+
+// SeatsConfig 浮动许可证席位配置
+// 客户端按租约有效期的三分之一发送心跳，崩溃的客户端占用的席位在租约到期后被回收
+type SeatsConfig struct {
+	LeaseTTL time.Duration `yaml:"lease_ttl"` // 席位租约有效期
+}
+
+// ExpiryConfig 网络验证的到期策略配置
+// 宽限期内网络验证仍然成功并返回 InGracePeriod，提醒期内返回 ExpiresSoon
+type ExpiryConfig struct {
+	GracePeriod   time.Duration `yaml:"grace_period"`   // 到期后的宽限期
+	WarningPeriod time.Duration `yaml:"warning_period"` // 到期前的提醒期
+}
+
+// DefaultConfig 返回默认配置
+// 返回值：
+//   - *Config: 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Host:            "0.0.0.0",
+			Port:            8081,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Path: "license.db",
+		},
+		CRL: CRLConfig{
+			Validity: 24 * time.Hour,
+		},
+		Seats: SeatsConfig{
+			LeaseTTL: server.DefaultLeaseTTL,
+		},
+	}
+}
+
+// LoadConfig 从YAML文件加载配置，未设置的字段使用默认值
+// 参数：
+//   - path: 配置文件路径
+//
+// 返回值：
+//   - *Config: 配置
+//   - error: 加载过程中的错误
+func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate 校验配置
+// 返回值：
+//   - error: 配置无效时的错误
+func (c *Config) Validate() error {
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		return fmt.Errorf("invalid server port: %d", c.Server.Port)
+	}
+	if c.Database.Path == "" {
+		return fmt.Errorf("database path is required")
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 || c.Server.ShutdownTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if c.CRL.Enabled && c.CRL.Validity <= 0 {
+		return fmt.Errorf("crl validity must be positive")
+	}
+	if c.Seats.LeaseTTL < 3*time.Second {
+		return fmt.Errorf("seat lease ttl must be at least 3s")
+	}
+	if c.Expiry.GracePeriod < 0 || c.Expiry.WarningPeriod < 0 {
+		return fmt.Errorf("expiry grace period and warning period must not be negative")
+	}
+	return nil
+}
+
+// Addr 返回监听地址（host:port）
+func (c *ServerConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
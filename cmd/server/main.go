@@ -0,0 +1,233 @@
+// Package main 实现独立的网络授权服务器 license-server
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
+	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
+	"github.com/Zeroshcat/LicenseManager/internal/server"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("license-server: %v", err)
+	}
+}
+
+// run 解析参数并运行服务器，直到收到退出信号
+// 参数：
+//   - args: 命令行参数
+//
+// 返回值：
+//   - error: 运行过程中的错误
+func run(args []string) error {
+	fs := flag.NewFlagSet("license-server", flag.ContinueOnError)
+	configPath := fs.String("config", "", "配置文件路径（YAML）")
+	host := fs.String("host", "", "监听地址（覆盖配置文件）")
+	port := fs.Int("port", 0, "监听端口（覆盖配置文件）")
+	dbPath := fs.String("db", "", "数据库文件路径（覆盖配置文件）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := loadConfig(*configPath, *host, *port, *dbPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(config.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// 使用口令解锁密钥环一次（双重验证需要AES密钥，吊销列表需要签名私钥），
+	// 无法解锁时密钥环保持锁定，仅禁用依赖私密密钥的功能
+	ring, err := keyring.Open(db, config.Keys.PassphraseFile)
+	if err != nil {
+		log.Printf("keyring locked: %v", err)
+		ring = keyring.New(db)
+	}
+
+	// 加载离线验证密钥（双重验证需要），缺失时仅禁用双重验证
+	verifier, err := loadVerifier(config.Keys, ring)
+	if err != nil {
+		log.Printf("dual verification disabled: %v", err)
+	}
+
+	licenseServer := server.NewServer(db, verifier)
+	licenseServer.SetLeaseTTL(config.Seats.LeaseTTL)
+	licenseServer.SetExpiryPolicy(license.ExpiryPolicy{
+		GracePeriod:   config.Expiry.GracePeriod,
+		WarningPeriod: config.Expiry.WarningPeriod,
+	})
+
+	// 启用时发布已签名的吊销列表
+	if config.CRL.Enabled {
+		signer, err := loadSigner(ring, config.CRL)
+		if err != nil {
+			return err
+		}
+		if closer, ok := signer.(io.Closer); ok {
+			defer closer.Close()
+		}
+		licenseServer.EnableCRL(signer, config.CRL.Validity)
+	}
+
+	httpServer := &http.Server{
+		Addr:         config.Server.Addr(),
+		Handler:      licenseServer,
+		ReadTimeout:  config.Server.ReadTimeout,
+		WriteTimeout: config.Server.WriteTimeout,
+		IdleTimeout:  config.Server.IdleTimeout,
+	}
+
+	// 在后台启动HTTP服务
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("license-server listening on %s", httpServer.Addr)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	// 等待退出信号或服务异常退出
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server error: %w", err)
+		}
+		return nil
+	case sig := <-stop:
+		log.Printf("received %s, shutting down", sig)
+	}
+
+	// 优雅关闭：停止接受新连接，等待进行中的请求完成
+	ctx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+
+	log.Printf("license-server stopped")
+	return nil
+}
+
+// loadConfig 加载配置文件并应用命令行覆盖
+// 参数：
+//   - path: 配置文件路径（为空时使用默认配置）
+//   - host: 监听地址覆盖值
+//   - port: 监听端口覆盖值
+//   - dbPath: 数据库路径覆盖值
+//
+// 返回值：
+//   - *Config: 最终配置
+//   - error: 加载过程中的错误
+func loadConfig(path, host string, port int, dbPath string) (*Config, error) {
+	config := DefaultConfig()
+	if path != "" {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		config = loaded
+	}
+
+	if host != "" {
+		config.Server.Host = host
+	}
+	if port != 0 {
+		config.Server.Port = port
+	}
+	if dbPath != "" {
+		config.Database.Path = dbPath
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// loadVerifier 加载公钥和AES密钥，创建离线许可证验证器
+// 受信任的公钥包括配置的公钥文件和密钥环中的全部公钥（激活 + 已退役），AES密钥从已解锁的密钥环读取；
+// 配置了公钥文件时文件必须可读（不使用公钥文件时将 keys.public_key 设为空）
+// 参数：
+//   - keys: 密钥配置
+//   - ring: 密钥环（未解锁时无法读取AES密钥）
+//
+// 返回值：
+//   - *licensegen.Verifier: 离线许可证验证器
+//   - error: 加载过程中的错误
+func loadVerifier(keys KeysConfig, ring *keyring.Keyring) (*licensegen.Verifier, error) {
+	publicKeyPEM, err := ring.PublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keyring public keys: %w", err)
+	}
+
+	if keys.PublicKey != "" {
+		filePEM, err := os.ReadFile(keys.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		publicKeyPEM = append(publicKeyPEM, filePEM...)
+	}
+
+	trustedKeys, err := license.NewKeySet(publicKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	aesKey, err := ring.AESKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AES key: %w", err)
+	}
+
+	return licensegen.NewVerifier(trustedKeys, aesKey), nil
+}
+
+// loadSigner 加载吊销列表签名私钥
+// 配置了 PKCS#11 设备时使用设备中的密钥（公钥登记到密钥环，与命令行签发时一致），否则使用密钥环中当前签发许可证的激活密钥
+// 参数：
+//   - ring: 密钥环（需要已解锁）
+//   - crl: 吊销列表配置
+//
+// 返回值：
+//   - crypto.Signer: 签名器
+//   - error: 加载过程中的错误
+func loadSigner(ring *keyring.Keyring, crl CRLConfig) (crypto.Signer, error) {
+	if crl.PKCS11.Enabled() {
+		signer, err := ring.OpenHSM(crl.PKCS11)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open CRL signing key: %w", err)
+		}
+		return signer, nil
+	}
+
+	signer, _, err := ring.Signer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CRL signing key: %w", err)
+	}
+
+	return signer, nil
+}
@@ -2,8 +2,8 @@
 package admin
 
 import (
-	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -15,6 +15,7 @@ import (
 
 	"github.com/Zeroshcat/LicenseManager/internal/crypto"
 	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
 	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
 	"github.com/Zeroshcat/LicenseManager/pkg/license"
 )
@@ -22,6 +23,7 @@ import (
 // WebAdmin Web管理界面
 type WebAdmin struct {
 	db       *database.DB
+	keyring  *keyring.Keyring // 已解锁的密钥环
 	template *template.Template
 	password string // 管理密码
 }
@@ -29,13 +31,15 @@ type WebAdmin struct {
 // NewWebAdmin 创建Web管理界面
 // 参数：
 //   - db: 数据库连接
+//   - ring: 已解锁的密钥环（签发许可证时使用）
 //   - password: 管理密码
 //
 // 返回值：
 //   - *WebAdmin: Web管理界面实例
-func NewWebAdmin(db *database.DB, password string) (*WebAdmin, error) {
+func NewWebAdmin(db *database.DB, ring *keyring.Keyring, password string) (*WebAdmin, error) {
 	admin := &WebAdmin{
 		db:       db,
+		keyring:  ring,
 		password: password,
 	}
 
@@ -101,6 +105,8 @@ func (w *WebAdmin) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		w.handleLicensesAPI(rw, r)
 	case "/api/licenses/generate":
 		w.handleGenerateLicense(rw, r)
+	case "/api/licenses/renew":
+		w.handleRenewLicense(rw, r)
 	case "/api/tokens":
 		w.handleTokensAPI(rw, r)
 	default:
@@ -121,6 +127,18 @@ func (w *WebAdmin) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 					"message": "Method not allowed",
 				})
 			}
+		} else if r.Method == http.MethodPost && strings.HasPrefix(path, "/api/licenses/") && strings.HasSuffix(path, "/revoke") {
+			// 撤销许可证: POST /api/licenses/{id}/revoke
+			w.handleRevokeLicense(rw, r)
+		} else if r.Method == http.MethodPost && strings.HasPrefix(path, "/api/licenses/") && strings.HasSuffix(path, "/unrevoke") {
+			// 恢复许可证: POST /api/licenses/{id}/unrevoke
+			w.handleUnrevokeLicense(rw, r)
+		} else if r.Method == http.MethodGet && strings.HasPrefix(path, "/api/licenses/") && strings.HasSuffix(path, "/activations") {
+			// 查看激活设备: GET /api/licenses/{id}/activations
+			w.handleLicenseActivations(rw, r)
+		} else if r.Method == http.MethodPost && strings.HasPrefix(path, "/api/licenses/") && strings.HasSuffix(path, "/deactivate") {
+			// 停用设备: POST /api/licenses/{id}/deactivate
+			w.handleDeactivateDevice(rw, r)
 		} else if r.Method == http.MethodPost && strings.HasSuffix(path, "/revoke") {
 			// 撤销Token: POST /api/tokens/{token}/revoke
 			w.handleRevokeToken(rw, r)
@@ -371,6 +389,140 @@ func (w *WebAdmin) handleDeleteLicense(rw http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handleRevokeLicense 处理撤销许可证
+// 请求体（可选）：{"reason": "撤销原因"}
+func (w *WebAdmin) handleRevokeLicense(rw http.ResponseWriter, r *http.Request) {
+	id, err := parseLicenseID(r.URL.Path, "/revoke")
+	if err != nil {
+		writeJSONResult(rw, http.StatusBadRequest, false, err.Error())
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONResult(rw, http.StatusBadRequest, false, "Invalid request body")
+			return
+		}
+	}
+
+	if err := w.db.RevokeLicense(id, req.Reason, "admin"); err != nil {
+		writeJSONResult(rw, http.StatusInternalServerError, false, "Failed to revoke license: "+err.Error())
+		return
+	}
+
+	writeJSONResult(rw, http.StatusOK, true, "License revoked")
+}
+
+// handleUnrevokeLicense 处理恢复已撤销的许可证
+func (w *WebAdmin) handleUnrevokeLicense(rw http.ResponseWriter, r *http.Request) {
+	id, err := parseLicenseID(r.URL.Path, "/unrevoke")
+	if err != nil {
+		writeJSONResult(rw, http.StatusBadRequest, false, err.Error())
+		return
+	}
+
+	if err := w.db.UnrevokeLicense(id); err != nil {
+		writeJSONResult(rw, http.StatusInternalServerError, false, "Failed to unrevoke license: "+err.Error())
+		return
+	}
+
+	writeJSONResult(rw, http.StatusOK, true, "License unrevoked")
+}
+
+// handleLicenseActivations 处理查看多设备许可证的激活设备
+func (w *WebAdmin) handleLicenseActivations(rw http.ResponseWriter, r *http.Request) {
+	id, err := parseLicenseID(r.URL.Path, "/activations")
+	if err != nil {
+		writeJSONResult(rw, http.StatusBadRequest, false, err.Error())
+		return
+	}
+
+	record, err := w.db.GetLicenseByID(id)
+	if err != nil {
+		writeJSONResult(rw, http.StatusNotFound, false, "License not found")
+		return
+	}
+
+	activations, err := w.db.ListActivations(record.ID)
+	if err != nil {
+		writeJSONResult(rw, http.StatusInternalServerError, false, "Failed to list activations: "+err.Error())
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"success":         true,
+		"license_id":      record.LicenseID,
+		"max_activations": record.MaxActivations,
+		"activations":     activations,
+	})
+}
+
+// handleDeactivateDevice 处理停用多设备许可证上的设备
+// 请求体：{"device_id": "设备ID"}
+func (w *WebAdmin) handleDeactivateDevice(rw http.ResponseWriter, r *http.Request) {
+	id, err := parseLicenseID(r.URL.Path, "/deactivate")
+	if err != nil {
+		writeJSONResult(rw, http.StatusBadRequest, false, err.Error())
+		return
+	}
+
+	var req struct {
+		DeviceID string `json:"device_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeviceID == "" {
+		writeJSONResult(rw, http.StatusBadRequest, false, "device_id is required")
+		return
+	}
+
+	if err := w.db.DeactivateDevice(id, req.DeviceID); err != nil {
+		if errors.Is(err, database.ErrNotActivated) {
+			writeJSONResult(rw, http.StatusNotFound, false, err.Error())
+			return
+		}
+		writeJSONResult(rw, http.StatusInternalServerError, false, "Failed to deactivate device: "+err.Error())
+		return
+	}
+
+	writeJSONResult(rw, http.StatusOK, true, "Device deactivated")
+}
+
+// parseLicenseID 从 /api/licenses/{id}{suffix} 格式的路径中提取许可证ID
+func parseLicenseID(path, suffix string) (int64, error) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(path, "/api/licenses/"), suffix)
+	if idStr == "" {
+		return 0, fmt.Errorf("License ID is required")
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid license ID: %s", idStr)
+	}
+
+	return id, nil
+}
+
+// parseDate 解析 YYYY-MM-DD 格式的日期，空字符串返回零值
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// writeJSONResult 写入 {"success": ..., "message": ...} 格式的JSON响应
+func writeJSONResult(rw http.ResponseWriter, status int, success bool, message string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"success": success,
+		"message": message,
+	})
+}
+
 // handleTokensAPI 处理Token API
 func (w *WebAdmin) handleTokensAPI(rw http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -435,9 +587,20 @@ func (w *WebAdmin) handleGenerateLicense(rw http.ResponseWriter, r *http.Request
 	}
 
 	var req struct {
-		DeviceID    string `json:"device_id"`
-		LicenseType string `json:"license_type"`
-		ExpiryDate  string `json:"expiry_date"`
+		DeviceID         string   `json:"device_id"`
+		ProductID        string   `json:"product_id"`
+		AppID            string   `json:"app_id"` // 所属应用ID（客户端Token只能访问所属应用的许可证）
+		VersionRange     string   `json:"version_range"`
+		LicenseType      string   `json:"license_type"`
+		Seats            int      `json:"seats"`           // 并发席位数（仅浮动许可证）
+		MaxActivations   int      `json:"max_activations"` // 最大激活设备数（多设备许可证）
+		TrialDays        int      `json:"trial_days"`      // 试用天数（仅试用许可证）
+		ExpiryDate       string   `json:"expiry_date"`
+		NotBefore        string   `json:"not_before"`        // 生效日期（可选）
+		Perpetual        bool     `json:"perpetual"`         // 永久许可证（不需要到期日期）
+		MaintenanceUntil string   `json:"maintenance_until"` // 维护截止日期（可选，仅永久许可证）
+		Features         []string `json:"features"`
+		Entitlements     []string `json:"entitlements"` // 授权项（name、name=<整数>、name=<字符串>）
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -445,25 +608,61 @@ func (w *WebAdmin) handleGenerateLicense(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// 验证必需参数
-	if req.DeviceID == "" {
+	// 验证必需参数（试用许可证可以不绑定设备）
+	if req.DeviceID == "" && req.LicenseType != string(license.LicenseTypeTrial) {
 		http.Error(rw, "device_id is required", http.StatusBadRequest)
 		return
 	}
-	if req.ExpiryDate == "" {
-		http.Error(rw, "expiry_date is required", http.StatusBadRequest)
+	if req.ExpiryDate == "" && !req.Perpetual {
+		http.Error(rw, "expiry_date is required unless the license is perpetual", http.StatusBadRequest)
 		return
 	}
 
-	// 解析到期时间
-	expiryDate, err := time.Parse("2006-01-02", req.ExpiryDate)
+	// 解析到期时间、生效时间和维护截止日期（为空时为零值）
+	expiryDate, err := parseDate(req.ExpiryDate)
 	if err != nil {
 		http.Error(rw, "Invalid expiry date format (use YYYY-MM-DD)", http.StatusBadRequest)
 		return
 	}
+	notBefore, err := parseDate(req.NotBefore)
+	if err != nil {
+		http.Error(rw, "Invalid not-before date format (use YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	maintenanceUntil, err := parseDate(req.MaintenanceUntil)
+	if err != nil {
+		http.Error(rw, "Invalid maintenance date format (use YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	// 验证版本范围
+	if req.VersionRange != "" {
+		if req.ProductID == "" {
+			http.Error(rw, "version_range requires product_id", http.StatusBadRequest)
+			return
+		}
+		if _, err := license.ParseVersionRange(req.VersionRange); err != nil {
+			http.Error(rw, "Invalid version range: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// 解析授权项
+	entitlements, err := license.ParseEntitlements(req.Entitlements)
+	if err != nil {
+		http.Error(rw, "Invalid entitlements: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var entitlementsJSON []byte
+	if len(entitlements) > 0 {
+		if entitlementsJSON, err = json.Marshal(entitlements); err != nil {
+			http.Error(rw, "Invalid entitlements: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// 加载密钥（需要从文件加载）
-	privateKey, aesKey, err := w.loadKeys()
+	signer, aesKey, err := w.loadKeys()
 	if err != nil {
 		http.Error(rw, "Failed to load keys: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -478,16 +677,63 @@ func (w *WebAdmin) handleGenerateLicense(rw http.ResponseWriter, r *http.Request
 		licType = license.LicenseTypeOnline
 	case "dual":
 		licType = license.LicenseTypeDual
+	case "floating":
+		licType = license.LicenseTypeFloating
+	case "trial":
+		licType = license.LicenseTypeTrial
 	default:
-		http.Error(rw, "Invalid license type (offline|online|dual)", http.StatusBadRequest)
+		http.Error(rw, "Invalid license type (offline|online|dual|floating|trial)", http.StatusBadRequest)
+		return
+	}
+	if licType == license.LicenseTypeFloating && req.Seats <= 0 {
+		http.Error(rw, "seats is required for floating licenses", http.StatusBadRequest)
+		return
+	}
+	if licType != license.LicenseTypeFloating && req.Seats != 0 {
+		http.Error(rw, "seats is only supported for floating licenses", http.StatusBadRequest)
+		return
+	}
+	if req.MaxActivations < 0 || (req.MaxActivations > 0 && licType != license.LicenseTypeOnline && licType != license.LicenseTypeDual) {
+		http.Error(rw, "max_activations must not be negative and is only supported for online and dual licenses", http.StatusBadRequest)
+		return
+	}
+	if licType == license.LicenseTypeTrial && req.TrialDays <= 0 {
+		http.Error(rw, "trial_days is required for trial licenses", http.StatusBadRequest)
+		return
+	}
+	if licType != license.LicenseTypeTrial && req.TrialDays != 0 {
+		http.Error(rw, "trial_days is only supported for trial licenses", http.StatusBadRequest)
+		return
+	}
+	if req.Perpetual && (req.ExpiryDate != "" || licType == license.LicenseTypeTrial) {
+		http.Error(rw, "perpetual licenses have no expiry_date and cannot be trial licenses", http.StatusBadRequest)
+		return
+	}
+	if !req.Perpetual && req.MaintenanceUntil != "" {
+		http.Error(rw, "maintenance_until is only supported for perpetual licenses", http.StatusBadRequest)
 		return
 	}
 
 	// 创建生成器
-	generator := licensegen.NewGenerator(privateKey, aesKey)
+	generator := licensegen.NewGenerator(signer, aesKey)
+	generator.SetDeviceKeys(w.db.GetDevicePublicKey)
+	generator.SetProduct(req.ProductID)
 
 	// 生成许可证
-	licenseKey, err := generator.Generate(req.DeviceID, licType, expiryDate, nil)
+	lic, licenseKey, err := generator.IssueWith(licensegen.IssueOptions{
+		DeviceID:         req.DeviceID,
+		VersionRange:     req.VersionRange,
+		LicenseType:      licType,
+		Seats:            req.Seats,
+		MaxActivations:   req.MaxActivations,
+		TrialDays:        req.TrialDays,
+		ExpiryDate:       expiryDate,
+		NotBefore:        notBefore,
+		Perpetual:        req.Perpetual,
+		MaintenanceUntil: maintenanceUntil,
+		Features:         req.Features,
+		Entitlements:     entitlements,
+	})
 	if err != nil {
 		http.Error(rw, "Failed to generate license: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -495,10 +741,22 @@ func (w *WebAdmin) handleGenerateLicense(rw http.ResponseWriter, r *http.Request
 
 	// 保存到数据库
 	licenseRecord := &database.LicenseRecord{
-		DeviceID:    req.DeviceID,
-		LicenseKey:  licenseKey,
-		LicenseType: req.LicenseType,
-		ExpiryDate:  expiryDate,
+		LicenseID:        lic.ID,
+		DeviceID:         req.DeviceID,
+		ProductID:        req.ProductID,
+		AppID:            req.AppID,
+		VersionRange:     req.VersionRange,
+		LicenseKey:       licenseKey,
+		LicenseType:      req.LicenseType,
+		Seats:            req.Seats,
+		MaxActivations:   req.MaxActivations,
+		TrialDays:        req.TrialDays,
+		Features:         strings.Join(req.Features, ","),
+		Entitlements:     string(entitlementsJSON),
+		ExpiryDate:       expiryDate,
+		NotBefore:        notBefore,
+		Perpetual:        req.Perpetual,
+		MaintenanceUntil: maintenanceUntil,
 	}
 
 	_, err = w.db.SaveLicense(licenseRecord)
@@ -512,11 +770,76 @@ func (w *WebAdmin) handleGenerateLicense(rw http.ResponseWriter, r *http.Request
 	json.NewEncoder(rw).Encode(map[string]interface{}{
 		"success":     true,
 		"license_key": licenseKey,
-		"license_id":  licenseRecord.ID,
+		"id":          licenseRecord.ID,
+		"license_id":  lic.ID,
 		"message":     "License generated successfully",
 	})
 }
 
+// handleRenewLicense 处理离线续期请求
+// 请求体：{"request": "续期请求文件内容", "expiry_date": "YYYY-MM-DD（可选，默认沿用原许可证的到期日期）"}
+// 签发的响应许可证通过 /api/licenses/{id}/download 下载后带回客户端安装
+func (w *WebAdmin) handleRenewLicense(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResult(rw, http.StatusMethodNotAllowed, false, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Request    string `json:"request"`     // 续期请求文件内容
+		ExpiryDate string `json:"expiry_date"` // 新的到期日期（可选）
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Request == "" {
+		writeJSONResult(rw, http.StatusBadRequest, false, "request is required")
+		return
+	}
+
+	expiryDate, err := parseDate(req.ExpiryDate)
+	if err != nil {
+		writeJSONResult(rw, http.StatusBadRequest, false, "Invalid expiry date format (use YYYY-MM-DD)")
+		return
+	}
+
+	renewal, err := license.ParseRenewalRequest([]byte(req.Request))
+	if err != nil {
+		writeJSONResult(rw, http.StatusBadRequest, false, err.Error())
+		return
+	}
+
+	signer, aesKey, err := w.loadKeys()
+	if err != nil {
+		writeJSONResult(rw, http.StatusInternalServerError, false, "Failed to load keys: "+err.Error())
+		return
+	}
+
+	generator := licensegen.NewGenerator(signer, aesKey)
+	record, err := licensegen.IssueRenewal(w.db, generator, renewal, expiryDate)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, license.ErrLicenseNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, license.ErrRevokedLicense), errors.Is(err, license.ErrDeviceMismatch),
+			errors.Is(err, license.ErrNotActivated), errors.Is(err, licensegen.ErrDeviceKeyMismatch):
+			status = http.StatusForbidden
+		}
+		writeJSONResult(rw, status, false, "Failed to renew license: "+err.Error())
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"success":            true,
+		"id":                 record.ID,
+		"license_id":         record.LicenseID,
+		"renewed_license_id": renewal.LicenseID,
+		"device_id":          record.DeviceID,
+		"perpetual":          record.Perpetual,
+		"expiry_date":        record.ExpiryDate,
+		"message":            "License renewed successfully",
+	})
+}
+
 // handleDownloadLicense 处理下载许可证文件
 func (w *WebAdmin) handleDownloadLicense(rw http.ResponseWriter, r *http.Request) {
 	// 从URL提取ID: /api/licenses/{id}/download
@@ -560,56 +883,20 @@ func (w *WebAdmin) handleDownloadLicense(rw http.ResponseWriter, r *http.Request
 	rw.Write([]byte(license.LicenseKey))
 }
 
-// loadKeys 加载密钥文件
-func (w *WebAdmin) loadKeys() (*rsa.PrivateKey, []byte, error) {
-	// 尝试多个可能的路径
-	keyPaths := []string{
-		"private_key.pem",
-		"./private_key.pem",
-		filepath.Join(filepath.Dir(os.Args[0]), "private_key.pem"),
-	}
-
-	var privateKeyPEM []byte
-	var readErr error
-	for _, path := range keyPaths {
-		if _, statErr := os.Stat(path); statErr == nil {
-			privateKeyPEM, readErr = os.ReadFile(path)
-			if readErr == nil {
-				break
-			}
-		}
-	}
-	if readErr != nil || privateKeyPEM == nil {
-		return nil, nil, fmt.Errorf("failed to read private key")
-	}
-
-	privateKey, err := crypto.DecodePrivateKey(privateKeyPEM)
+// loadKeys 从密钥环加载签名密钥和AES密钥
+// 旧版本生成的密钥文件需要先通过 key init 导入密钥环
+func (w *WebAdmin) loadKeys() (crypto.Signer, []byte, error) {
+	signer, _, err := w.keyring.Signer()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to decode private key: %w", err)
+		return nil, nil, fmt.Errorf("failed to load signing key: %w", err)
 	}
 
-	// 加载AES密钥
-	aesPaths := []string{
-		"aes_key.bin",
-		"./aes_key.bin",
-		filepath.Join(filepath.Dir(os.Args[0]), "aes_key.bin"),
-	}
-
-	var aesKey []byte
-	var aesReadErr error
-	for _, path := range aesPaths {
-		if _, statErr := os.Stat(path); statErr == nil {
-			aesKey, aesReadErr = os.ReadFile(path)
-			if aesReadErr == nil {
-				break
-			}
-		}
-	}
-	if aesReadErr != nil || aesKey == nil {
-		return nil, nil, fmt.Errorf("failed to read AES key")
+	aesKey, err := w.keyring.AESKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load AES key: %w", err)
 	}
 
-	return privateKey, aesKey, nil
+	return signer, aesKey, nil
 }
 
 // loginPageHTML 登录页面HTML
@@ -4,8 +4,41 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+)
+
+// Token类型
+const (
+	// TokenTypeClient 客户端Token，绑定到应用ID
+	TokenTypeClient = "client"
+
+	// TokenTypeAdmin 管理员Token，可访问所有应用
+	TokenTypeAdmin = "admin"
+)
+
+// 定义Token验证相关的错误
+var (
+	// ErrMissingToken 表示请求未携带Token
+	ErrMissingToken = errors.New("missing token")
+
+	// ErrInvalidToken 表示Token不存在
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrTokenRevoked 表示Token已被撤销
+	ErrTokenRevoked = errors.New("token revoked")
+
+	// ErrTokenExpired 表示Token已过期
+	ErrTokenExpired = errors.New("token expired")
+
+	// ErrTokenTypeMismatch 表示Token类型不满足要求
+	ErrTokenTypeMismatch = errors.New("token type mismatch")
+
+	// ErrAppIDMismatch 表示Token绑定的应用ID与请求不一致
+	ErrAppIDMismatch = errors.New("app ID mismatch")
 )
 
 // GenerateToken 生成随机Token
@@ -23,18 +56,60 @@ func GenerateToken(length int) (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
-// ValidateToken 验证Token是否有效
+// LookupToken 查询Token并检查是否已撤销或过期
 // 参数：
+//   - db: 数据库连接
 //   - token: Token值
-//   - tokenType: Token类型（client|admin）
-//   - appID: 应用ID（client类型需要）
 // 返回值：
-//   - bool: Token是否有效
-//   - error: 验证过程中的错误
-func ValidateToken(token string, tokenType string, appID string) (bool, error) {
-	// 这里应该查询数据库验证Token
-	// 简化实现，返回true
-	return true, nil
+//   - *database.TokenRecord: Token记录
+//   - error: Token无效的原因
+func LookupToken(db *database.DB, token string) (*database.TokenRecord, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	record, err := db.GetToken(token)
+	if err != nil {
+		if errors.Is(err, database.ErrTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to query token: %w", err)
+	}
+
+	if record.Revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	return record, nil
+}
+
+// CheckToken 检查Token的类型和应用ID
+// 管理员Token满足任何类型要求，且不受应用ID限制；
+// 客户端Token只能访问其绑定的应用，未绑定应用ID的客户端Token不能访问任何应用
+// 参数：
+//   - record: Token记录
+//   - tokenType: 要求的Token类型（client|admin，为空表示不限制）
+//   - appID: 请求的应用ID
+// 返回值：
+//   - error: 检查失败的原因
+func CheckToken(record *database.TokenRecord, tokenType string, appID string) error {
+	if record.TokenType == TokenTypeAdmin {
+		return nil
+	}
+
+	if tokenType == TokenTypeAdmin {
+		return ErrTokenTypeMismatch
+	}
+
+	if record.AppID == "" || record.AppID != appID {
+		return ErrAppIDMismatch
+	}
+
+	return nil
 }
 
 // TokenExpiry 计算Token过期时间
@@ -49,5 +124,3 @@ func TokenExpiry(days int) time.Time {
 	}
 	return time.Now().AddDate(0, 0, days)
 }
-
-
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+)
+
+func TestCheckToken(t *testing.T) {
+	client := &database.TokenRecord{TokenType: TokenTypeClient, AppID: "app-a"}
+	unscoped := &database.TokenRecord{TokenType: TokenTypeClient}
+	admin := &database.TokenRecord{TokenType: TokenTypeAdmin}
+
+	tests := []struct {
+		name      string
+		record    *database.TokenRecord
+		tokenType string
+		appID     string
+		wantErr   error
+	}{
+		{"client same app", client, "", "app-a", nil},
+		{"client requires client", client, TokenTypeClient, "app-a", nil},
+		{"client other app", client, "", "app-b", ErrAppIDMismatch},
+		{"client empty app", client, "", "", ErrAppIDMismatch},
+		{"client requires admin", client, TokenTypeAdmin, "app-a", ErrTokenTypeMismatch},
+		// 未绑定应用ID的客户端Token不能访问任何应用
+		{"unscoped client", unscoped, "", "app-a", ErrAppIDMismatch},
+		{"unscoped client empty app", unscoped, "", "", ErrAppIDMismatch},
+		{"admin any app", admin, "", "app-b", nil},
+		{"admin requires admin", admin, TokenTypeAdmin, "", nil},
+		{"admin requires client", admin, TokenTypeClient, "app-a", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckToken(tc.record, tc.tokenType, tc.appID); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("CheckToken: err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
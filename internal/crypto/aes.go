@@ -18,6 +18,20 @@ import (
 //   - []byte: 加密后的数据（包含nonce和密文）
 //   - error: 加密过程中的错误
 func EncryptAES(plaintext []byte, key []byte) ([]byte, error) {
+	return EncryptAESWithAAD(plaintext, key, nil)
+}
+
+// EncryptAESWithAAD 使用AES-256-GCM加密数据，并认证附加数据（AAD）
+// 附加数据不加密、不包含在输出中，解密时必须提供相同的附加数据
+// 参数：
+//   - plaintext: 明文数据
+//   - key: 32字节的密钥（AES-256需要32字节）
+//   - aad: 附加数据（例如许可证头部，可为 nil）
+//
+// 返回值：
+//   - []byte: 加密后的数据（包含nonce和密文）
+//   - error: 加密过程中的错误
+func EncryptAESWithAAD(plaintext []byte, key []byte, aad []byte) ([]byte, error) {
 	// 验证密钥长度
 	if len(key) != 32 {
 		return nil, errors.New("key must be 32 bytes for AES-256")
@@ -42,7 +56,7 @@ func EncryptAES(plaintext []byte, key []byte) ([]byte, error) {
 	}
 
 	// 加密
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, aad)
 	return ciphertext, nil
 }
 
@@ -55,6 +69,19 @@ func EncryptAES(plaintext []byte, key []byte) ([]byte, error) {
 //   - []byte: 解密后的明文数据
 //   - error: 解密过程中的错误
 func DecryptAES(ciphertext []byte, key []byte) ([]byte, error) {
+	return DecryptAESWithAAD(ciphertext, key, nil)
+}
+
+// DecryptAESWithAAD 使用AES-256-GCM解密数据，并校验附加数据（AAD）
+// 参数：
+//   - ciphertext: 密文数据（包含nonce和密文）
+//   - key: 32字节的密钥
+//   - aad: 加密时使用的附加数据（可为 nil）
+//
+// 返回值：
+//   - []byte: 解密后的明文数据
+//   - error: 解密过程中的错误（附加数据不一致时认证失败）
+func DecryptAESWithAAD(ciphertext []byte, key []byte, aad []byte) ([]byte, error) {
 	// 验证密钥长度
 	if len(key) != 32 {
 		return nil, errors.New("key must be 32 bytes for AES-256")
@@ -81,7 +108,7 @@ func DecryptAES(ciphertext []byte, key []byte) ([]byte, error) {
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
 
 	// 解密
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
 	if err != nil {
 		return nil, err
 	}
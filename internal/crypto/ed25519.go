@@ -0,0 +1,93 @@
+// Package crypto 提供加密和解密功能
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// GenerateEd25519KeyPair 生成Ed25519密钥对
+// 返回值：
+//   - ed25519.PrivateKey: 私钥
+//   - ed25519.PublicKey: 公钥
+//   - error: 生成过程中的错误
+func GenerateEd25519KeyPair() (ed25519.PrivateKey, ed25519.PublicKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// SignEd25519 使用Ed25519私钥签名数据
+// 参数：
+//   - data: 要签名的数据
+//   - privateKey: Ed25519私钥
+//
+// 返回值：
+//   - []byte: 签名数据（64字节）
+func SignEd25519(data []byte, privateKey ed25519.PrivateKey) []byte {
+	return ed25519.Sign(privateKey, data)
+}
+
+// VerifyEd25519 使用Ed25519公钥验证签名
+// 参数：
+//   - data: 原始数据
+//   - signature: 签名数据
+//   - publicKey: Ed25519公钥
+//
+// 返回值：
+//   - bool: 签名是否有效
+func VerifyEd25519(data []byte, signature []byte, publicKey ed25519.PublicKey) bool {
+	return ed25519.Verify(publicKey, data, signature)
+}
+
+// EncodeEd25519PrivateKey 将Ed25519私钥编码为PEM格式（PKCS#8）
+// 参数：
+//   - privateKey: Ed25519私钥
+//
+// 返回值：
+//   - []byte: PEM编码的私钥
+//   - error: 编码过程中的错误
+func EncodeEd25519PrivateKey(privateKey ed25519.PrivateKey) ([]byte, error) {
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: keyBytes,
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// DecodeEd25519PrivateKey 从PEM格式解码Ed25519私钥
+// 参数：
+//   - pemData: PEM编码的私钥数据
+//
+// 返回值：
+//   - ed25519.PrivateKey: Ed25519私钥
+//   - error: 解码过程中的错误
+func DecodeEd25519PrivateKey(pemData []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	edPrivateKey, ok := privateKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an Ed25519 private key")
+	}
+
+	return edPrivateKey, nil
+}
@@ -0,0 +1,109 @@
+// Package crypto 提供加密和解密功能
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// productKeyInfo 派生产品密钥时使用的 HKDF info 前缀（与产品ID拼接，用于区分用途）
+const productKeyInfo = "LicenseManager license key v1:"
+
+// renewalKeyInfo 派生离线续期请求MAC密钥时使用的 HKDF info
+const renewalKeyInfo = "LicenseManager renewal request v1"
+
+// KDFParams Argon2id 密钥派生参数
+// 参数随派生结果一起保存，调整默认值不影响已有数据的解锁
+type KDFParams struct {
+	Salt    []byte `json:"salt"`    // 随机盐（16字节）
+	Time    uint32 `json:"time"`    // 迭代次数
+	Memory  uint32 `json:"memory"`  // 内存开销（KiB）
+	Threads uint8  `json:"threads"` // 并行度
+}
+
+// NewKDFParams 使用默认开销和新的随机盐创建密钥派生参数
+// 返回值：
+//   - *KDFParams: 密钥派生参数
+//   - error: 生成盐时的错误
+func NewKDFParams() (*KDFParams, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return &KDFParams{
+		Salt:    salt,
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}, nil
+}
+
+// DeriveKey 使用 Argon2id 从口令派生32字节的AES-256密钥
+// 参数：
+//   - passphrase: 口令
+//   - params: 密钥派生参数
+//
+// 返回值：
+//   - []byte: 派生的密钥（32字节）
+//   - error: 参数无效时的错误
+func DeriveKey(passphrase []byte, params *KDFParams) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	if len(params.Salt) == 0 || params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+
+	return argon2.IDKey(passphrase, params.Salt, params.Time, params.Memory, params.Threads, 32), nil
+}
+
+// DeriveProductKey 使用 HKDF-SHA256 从主AES密钥派生产品（或客户）专用的AES密钥
+// 持有产品密钥只能解密该产品的许可证，无法反推主密钥或其他产品的密钥
+// 参数：
+//   - masterKey: 主AES密钥（32字节）
+//   - productID: 产品或客户ID
+//
+// 返回值：
+//   - []byte: 派生的AES密钥（32字节）
+//   - error: 参数无效时的错误
+func DeriveProductKey(masterKey []byte, productID string) ([]byte, error) {
+	if len(masterKey) != 32 {
+		return nil, errors.New("master key must be 32 bytes")
+	}
+	if productID == "" {
+		return nil, errors.New("product ID must not be empty")
+	}
+
+	key := make([]byte, 32)
+	reader := hkdf.New(sha256.New, masterKey, nil, []byte(productKeyInfo+productID))
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeriveRenewalKey 使用 HKDF-SHA256 从AES密钥（主密钥或产品密钥）派生离线续期请求的MAC密钥
+// 参数：
+//   - aesKey: AES密钥（32字节，产品许可证为产品密钥）
+//
+// 返回值：
+//   - []byte: 派生的MAC密钥（32字节）
+//   - error: 参数无效时的错误
+func DeriveRenewalKey(aesKey []byte) ([]byte, error) {
+	if len(aesKey) != 32 {
+		return nil, errors.New("AES key must be 32 bytes")
+	}
+
+	key := make([]byte, 32)
+	reader := hkdf.New(sha256.New, aesKey, nil, []byte(renewalKeyInfo))
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
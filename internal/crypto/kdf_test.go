@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveProductKey(t *testing.T) {
+	masterKey := bytes.Repeat([]byte{0x01}, 32)
+	otherMasterKey := bytes.Repeat([]byte{0x02}, 32)
+
+	acme, err := DeriveProductKey(masterKey, "acme")
+	if err != nil {
+		t.Fatalf("DeriveProductKey: %v", err)
+	}
+	if len(acme) != 32 {
+		t.Fatalf("product key length = %d, want 32", len(acme))
+	}
+	if bytes.Equal(acme, masterKey) {
+		t.Fatal("product key equals master key")
+	}
+
+	again, err := DeriveProductKey(masterKey, "acme")
+	if err != nil {
+		t.Fatalf("DeriveProductKey: %v", err)
+	}
+	if !bytes.Equal(acme, again) {
+		t.Fatal("DeriveProductKey is not deterministic")
+	}
+
+	// 不同产品或不同主密钥派生的密钥互不相同
+	for _, tc := range []struct {
+		name      string
+		masterKey []byte
+		productID string
+	}{
+		{"other product", masterKey, "globex"},
+		{"product ID prefix", masterKey, "acm"},
+		{"product ID suffix", masterKey, "acme2"},
+		{"other master key", otherMasterKey, "acme"},
+	} {
+		key, err := DeriveProductKey(tc.masterKey, tc.productID)
+		if err != nil {
+			t.Fatalf("DeriveProductKey %s: %v", tc.name, err)
+		}
+		if bytes.Equal(key, acme) {
+			t.Fatalf("DeriveProductKey %s returned the same key", tc.name)
+		}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		masterKey []byte
+		productID string
+	}{
+		{"short master key", masterKey[:16], "acme"},
+		{"nil master key", nil, "acme"},
+		{"empty product", masterKey, ""},
+	} {
+		if _, err := DeriveProductKey(tc.masterKey, tc.productID); err == nil {
+			t.Fatalf("DeriveProductKey %s succeeded, want error", tc.name)
+		}
+	}
+}
+
+func TestProductKeyIsolation(t *testing.T) {
+	masterKey := bytes.Repeat([]byte{0x01}, 32)
+	acme, err := DeriveProductKey(masterKey, "acme")
+	if err != nil {
+		t.Fatalf("DeriveProductKey: %v", err)
+	}
+	globex, err := DeriveProductKey(masterKey, "globex")
+	if err != nil {
+		t.Fatalf("DeriveProductKey: %v", err)
+	}
+
+	aad := []byte("header")
+	ciphertext, err := EncryptAESWithAAD([]byte("license"), acme, aad)
+	if err != nil {
+		t.Fatalf("EncryptAESWithAAD: %v", err)
+	}
+
+	// 只有对应产品的密钥可以解密
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr bool
+	}{
+		{"product key", acme, false},
+		{"other product key", globex, true},
+		{"master key", masterKey, true},
+	}
+	for _, tc := range tests {
+		plaintext, err := DecryptAESWithAAD(ciphertext, tc.key, aad)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("DecryptAESWithAAD %s: err = %v, want error %v", tc.name, err, tc.wantErr)
+		}
+		if !tc.wantErr && string(plaintext) != "license" {
+			t.Fatalf("DecryptAESWithAAD %s = %q, want %q", tc.name, plaintext, "license")
+		}
+	}
+}
@@ -0,0 +1,393 @@
+// Package crypto 提供加密和解密功能
+package crypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Algorithm 签名算法
+type Algorithm string
+
+const (
+	// AlgorithmRSA RSA-4096 PSS + SHA-256
+	AlgorithmRSA Algorithm = "rsa"
+
+	// AlgorithmEd25519 Ed25519（签名仅64字节，许可证更短）
+	AlgorithmEd25519 Algorithm = "ed25519"
+)
+
+// ParseAlgorithm 解析签名算法名称
+// 参数：
+//   - name: 算法名称（rsa|ed25519）
+//
+// 返回值：
+//   - Algorithm: 签名算法
+//   - error: 算法不支持时的错误
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(name) {
+	case AlgorithmRSA, AlgorithmEd25519:
+		return Algorithm(name), nil
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm: %s (rsa|ed25519)", name)
+	}
+}
+
+// Signer 签名器接口，生成许可证和吊销列表时使用
+type Signer interface {
+	// Algorithm 返回签名算法
+	Algorithm() Algorithm
+
+	// Sign 签名数据
+	Sign(data []byte) ([]byte, error)
+
+	// Public 返回签名密钥对应的公钥
+	Public() crypto.PublicKey
+}
+
+// Verifier 签名验证器接口，验证许可证和吊销列表时使用
+type Verifier interface {
+	// Algorithm 返回签名算法
+	Algorithm() Algorithm
+
+	// Verify 验证签名，签名有效时返回 true
+	Verify(data []byte, signature []byte) bool
+
+	// Public 返回验证使用的公钥
+	Public() crypto.PublicKey
+}
+
+// rsaSigner RSA签名器
+type rsaSigner struct {
+	privateKey *rsa.PrivateKey
+}
+
+// NewRSASigner 创建RSA签名器
+// 参数：
+//   - privateKey: RSA私钥
+//
+// 返回值：
+//   - Signer: 签名器
+func NewRSASigner(privateKey *rsa.PrivateKey) Signer {
+	return &rsaSigner{privateKey: privateKey}
+}
+
+func (s *rsaSigner) Algorithm() Algorithm {
+	return AlgorithmRSA
+}
+
+func (s *rsaSigner) Sign(data []byte) ([]byte, error) {
+	return SignData(data, s.privateKey)
+}
+
+func (s *rsaSigner) Public() crypto.PublicKey {
+	return &s.privateKey.PublicKey
+}
+
+// rsaVerifier RSA签名验证器
+type rsaVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+// NewRSAVerifier 创建RSA签名验证器
+// 参数：
+//   - publicKey: RSA公钥
+//
+// 返回值：
+//   - Verifier: 签名验证器
+func NewRSAVerifier(publicKey *rsa.PublicKey) Verifier {
+	return &rsaVerifier{publicKey: publicKey}
+}
+
+func (v *rsaVerifier) Algorithm() Algorithm {
+	return AlgorithmRSA
+}
+
+func (v *rsaVerifier) Verify(data []byte, signature []byte) bool {
+	valid, err := VerifySignature(data, signature, v.publicKey)
+	return err == nil && valid
+}
+
+func (v *rsaVerifier) Public() crypto.PublicKey {
+	return v.publicKey
+}
+
+// ed25519Signer Ed25519签名器
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewEd25519Signer 创建Ed25519签名器
+// 参数：
+//   - privateKey: Ed25519私钥
+//
+// 返回值：
+//   - Signer: 签名器
+func NewEd25519Signer(privateKey ed25519.PrivateKey) Signer {
+	return &ed25519Signer{privateKey: privateKey}
+}
+
+func (s *ed25519Signer) Algorithm() Algorithm {
+	return AlgorithmEd25519
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return SignEd25519(data, s.privateKey), nil
+}
+
+func (s *ed25519Signer) Public() crypto.PublicKey {
+	return s.privateKey.Public()
+}
+
+// ed25519Verifier Ed25519签名验证器
+type ed25519Verifier struct {
+	publicKey ed25519.PublicKey
+}
+
+// NewEd25519Verifier 创建Ed25519签名验证器
+// 参数：
+//   - publicKey: Ed25519公钥
+//
+// 返回值：
+//   - Verifier: 签名验证器
+func NewEd25519Verifier(publicKey ed25519.PublicKey) Verifier {
+	return &ed25519Verifier{publicKey: publicKey}
+}
+
+func (v *ed25519Verifier) Algorithm() Algorithm {
+	return AlgorithmEd25519
+}
+
+func (v *ed25519Verifier) Verify(data []byte, signature []byte) bool {
+	return VerifyEd25519(data, signature, v.publicKey)
+}
+
+func (v *ed25519Verifier) Public() crypto.PublicKey {
+	return v.publicKey
+}
+
+// stdSigner 将标准库 crypto.Signer 适配为 Signer
+// 私钥由实现方持有（例如 PKCS#11 设备），签名时只传入摘要或原始数据
+type stdSigner struct {
+	signer    crypto.Signer
+	algorithm Algorithm
+}
+
+// NewSigner 将标准库 crypto.Signer 适配为签名器，根据公钥类型选择签名算法
+// RSA 密钥使用 PSS + SHA-256（与 SignData 一致），Ed25519 密钥直接签名原始数据
+// 参数：
+//   - signer: 标准库签名器（例如 HSM 中的私钥句柄）
+//
+// 返回值：
+//   - Signer: 签名器
+//   - error: 公钥类型不支持时的错误
+func NewSigner(signer crypto.Signer) (Signer, error) {
+	switch key := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return &stdSigner{signer: signer, algorithm: AlgorithmRSA}, nil
+	case ed25519.PublicKey:
+		return &stdSigner{signer: signer, algorithm: AlgorithmEd25519}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", key)
+	}
+}
+
+func (s *stdSigner) Algorithm() Algorithm {
+	return s.algorithm
+}
+
+func (s *stdSigner) Sign(data []byte) ([]byte, error) {
+	if s.algorithm == AlgorithmEd25519 {
+		return s.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+
+	hash := sha256.Sum256(data)
+	opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	return s.signer.Sign(rand.Reader, hash[:], opts)
+}
+
+func (s *stdSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+// KeyID 计算公钥的密钥ID
+// 密钥ID为公钥 PKIX DER 编码的 SHA-256 前8字节（十六进制），写入许可证头部用于选择验证公钥
+// 参数：
+//   - publicKey: 公钥（*rsa.PublicKey 或 ed25519.PublicKey）
+//
+// 返回值：
+//   - string: 16位十六进制密钥ID
+//   - error: 公钥类型不支持时的错误
+func KeyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:8]), nil
+}
+
+// EncodePublicKeyPEM 将公钥编码为PEM格式（PKIX，支持RSA和Ed25519）
+// 参数：
+//   - publicKey: 公钥
+//
+// 返回值：
+//   - []byte: PEM编码的公钥
+//   - error: 编码过程中的错误
+func EncodePublicKeyPEM(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// GenerateKeyPair 按算法生成签名密钥对
+// 参数：
+//   - algorithm: 签名算法
+//
+// 返回值：
+//   - []byte: PEM编码的私钥
+//   - []byte: PEM编码的公钥
+//   - error: 生成过程中的错误
+func GenerateKeyPair(algorithm Algorithm) ([]byte, []byte, error) {
+	switch algorithm {
+	case AlgorithmRSA:
+		privateKey, publicKey, err := GenerateRSAKeyPair()
+		if err != nil {
+			return nil, nil, err
+		}
+		return EncodePrivateKey(privateKey), EncodePublicKey(publicKey), nil
+
+	case AlgorithmEd25519:
+		privateKey, publicKey, err := GenerateEd25519KeyPair()
+		if err != nil {
+			return nil, nil, err
+		}
+		privateKeyPEM, err := EncodeEd25519PrivateKey(privateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKeyPEM, err := EncodePublicKeyPEM(publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return privateKeyPEM, publicKeyPEM, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported signature algorithm: %s", algorithm)
+	}
+}
+
+// ParseSigner 从PEM格式私钥创建签名器，根据PEM类型自动识别算法
+// 参数：
+//   - pemData: PEM编码的私钥（RSA PRIVATE KEY 或 PKCS#8 Ed25519 PRIVATE KEY）
+//
+// 返回值：
+//   - Signer: 签名器
+//   - error: 解码过程中的错误
+func ParseSigner(pemData []byte) (Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSASigner(privateKey), nil
+
+	case "PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key := privateKey.(type) {
+		case ed25519.PrivateKey:
+			return NewEd25519Signer(key), nil
+		case *rsa.PrivateKey:
+			return NewRSASigner(key), nil
+		default:
+			return nil, fmt.Errorf("unsupported private key type: %T", privateKey)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
+
+// ParseVerifier 从PEM格式公钥创建签名验证器，根据公钥类型自动识别算法
+// 参数：
+//   - pemData: PEM编码的公钥（PKIX）
+//
+// 返回值：
+//   - Verifier: 签名验证器
+//   - error: 解码过程中的错误
+func ParseVerifier(pemData []byte) (Verifier, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return NewRSAVerifier(key), nil
+	case ed25519.PublicKey:
+		return NewEd25519Verifier(key), nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+}
+
+// ParseVerifiers 从包含一个或多个PEM公钥的数据创建签名验证器
+// 用于加载密钥轮换后的公钥集合（当前公钥 + 已退役公钥）
+// 参数：
+//   - pemData: PEM编码的公钥（可包含多个 PUBLIC KEY 块）
+//
+// 返回值：
+//   - []Verifier: 签名验证器列表
+//   - error: 解码过程中的错误
+func ParseVerifiers(pemData []byte) ([]Verifier, error) {
+	var verifiers []Verifier
+	rest := pemData
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		verifier, err := ParseVerifier(pem.EncodeToMemory(block))
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, verifier)
+	}
+
+	if len(verifiers) == 0 {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	return verifiers, nil
+}
@@ -0,0 +1,172 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Algorithm
+		wantErr bool
+	}{
+		{"rsa", AlgorithmRSA, false},
+		{"ed25519", AlgorithmEd25519, false},
+		{"", "", true},
+		{"ecdsa", "", true},
+		{"Ed25519", "", true},
+	}
+	for _, tc := range tests {
+		got, err := ParseAlgorithm(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("ParseAlgorithm(%q) = %q, %v; want %q, error %v", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	data := []byte("license payload")
+
+	for _, algorithm := range []Algorithm{AlgorithmRSA, AlgorithmEd25519} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			privateKeyPEM, publicKeyPEM, err := GenerateKeyPair(algorithm)
+			if err != nil {
+				t.Fatalf("GenerateKeyPair: %v", err)
+			}
+			signer, err := ParseSigner(privateKeyPEM)
+			if err != nil {
+				t.Fatalf("ParseSigner: %v", err)
+			}
+			verifier, err := ParseVerifier(publicKeyPEM)
+			if err != nil {
+				t.Fatalf("ParseVerifier: %v", err)
+			}
+			if signer.Algorithm() != algorithm || verifier.Algorithm() != algorithm {
+				t.Fatalf("Algorithm = %s/%s, want %s", signer.Algorithm(), verifier.Algorithm(), algorithm)
+			}
+
+			signerID, err := KeyID(signer.Public())
+			if err != nil {
+				t.Fatalf("KeyID: %v", err)
+			}
+			verifierID, err := KeyID(verifier.Public())
+			if err != nil {
+				t.Fatalf("KeyID: %v", err)
+			}
+			if signerID != verifierID || len(signerID) != 16 {
+				t.Fatalf("KeyID = %q/%q, want equal 16-digit IDs", signerID, verifierID)
+			}
+
+			signature, err := signer.Sign(data)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			if algorithm == AlgorithmEd25519 && len(signature) != 64 {
+				t.Fatalf("Ed25519 signature length = %d, want 64", len(signature))
+			}
+
+			tamperedSignature := bytes.Clone(signature)
+			tamperedSignature[0] ^= 0xff
+
+			tests := []struct {
+				name      string
+				data      []byte
+				signature []byte
+				want      bool
+			}{
+				{"valid", data, signature, true},
+				{"tampered data", []byte("license payloaD"), signature, false},
+				{"tampered signature", data, tamperedSignature, false},
+				{"truncated signature", data, signature[:len(signature)-1], false},
+				{"empty signature", data, nil, false},
+			}
+			for _, tc := range tests {
+				if got := verifier.Verify(tc.data, tc.signature); got != tc.want {
+					t.Fatalf("Verify %s = %v, want %v", tc.name, got, tc.want)
+				}
+			}
+
+			// 其他密钥无法验证签名
+			_, otherPublicKeyPEM, err := GenerateKeyPair(algorithm)
+			if err != nil {
+				t.Fatalf("GenerateKeyPair: %v", err)
+			}
+			other, err := ParseVerifier(otherPublicKeyPEM)
+			if err != nil {
+				t.Fatalf("ParseVerifier: %v", err)
+			}
+			if other.Verify(data, signature) {
+				t.Fatal("Verify with other key = true, want false")
+			}
+		})
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	data := []byte("license payload")
+
+	rsaPrivateKey, rsaPublicKey, err := GenerateRSAKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateRSAKeyPair: %v", err)
+	}
+	edPrivateKey, edPublicKey, err := GenerateEd25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateEd25519KeyPair: %v", err)
+	}
+
+	// 标准库签名器（例如 HSM 私钥句柄）的签名与内置签名器的验证器兼容
+	tests := []struct {
+		name     string
+		signer   crypto.Signer
+		verifier Verifier
+		want     Algorithm
+	}{
+		{"rsa", rsaPrivateKey, NewRSAVerifier(rsaPublicKey), AlgorithmRSA},
+		{"ed25519", edPrivateKey, NewEd25519Verifier(edPublicKey), AlgorithmEd25519},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := NewSigner(tc.signer)
+			if err != nil {
+				t.Fatalf("NewSigner: %v", err)
+			}
+			if signer.Algorithm() != tc.want {
+				t.Fatalf("Algorithm = %s, want %s", signer.Algorithm(), tc.want)
+			}
+			signature, err := signer.Sign(data)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			if !tc.verifier.Verify(data, signature) {
+				t.Fatal("Verify = false, want true")
+			}
+		})
+	}
+}
+
+func TestParseVerifiers(t *testing.T) {
+	_, rsaPublicKeyPEM, err := GenerateKeyPair(AlgorithmRSA)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	_, edPublicKeyPEM, err := GenerateKeyPair(AlgorithmEd25519)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+
+	verifiers, err := ParseVerifiers(append(bytes.Clone(rsaPublicKeyPEM), edPublicKeyPEM...))
+	if err != nil {
+		t.Fatalf("ParseVerifiers: %v", err)
+	}
+	if len(verifiers) != 2 || verifiers[0].Algorithm() != AlgorithmRSA || verifiers[1].Algorithm() != AlgorithmEd25519 {
+		t.Fatalf("ParseVerifiers returned %d verifiers, want rsa and ed25519", len(verifiers))
+	}
+
+	for _, data := range [][]byte{nil, []byte("not a key")} {
+		if _, err := ParseVerifiers(data); err == nil {
+			t.Fatalf("ParseVerifiers(%q) succeeded, want error", data)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package crypto 提供加密和解密功能
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// x25519KeyInfo 由共享密钥派生AES密钥时使用的 HKDF info
+const x25519KeyInfo = "LicenseManager device key v1"
+
+// GenerateX25519KeyPair 生成X25519密钥对（设备密钥）
+// 返回值：
+//   - []byte: 私钥（32字节）
+//   - []byte: 公钥（32字节）
+//   - error: 生成过程中的错误
+func GenerateX25519KeyPair() ([]byte, []byte, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey.Bytes(), privateKey.PublicKey().Bytes(), nil
+}
+
+// X25519PublicKey 根据X25519私钥计算公钥
+// 参数：
+//   - privateKey: 私钥（32字节）
+//
+// 返回值：
+//   - []byte: 公钥（32字节）
+//   - error: 私钥无效时的错误
+func X25519PublicKey(privateKey []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey().Bytes(), nil
+}
+
+// X25519KeyID 计算X25519公钥的密钥ID（SHA-256 前8字节，十六进制）
+// 参数：
+//   - publicKey: 公钥（32字节）
+//
+// 返回值：
+//   - string: 16位十六进制密钥ID
+func X25519KeyID(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+	return hex.EncodeToString(hash[:8])
+}
+
+// EncryptX25519 使用接收方的X25519公钥加密数据（ECIES：临时密钥协商 + HKDF-SHA256 + AES-256-GCM）
+// 参数：
+//   - plaintext: 明文数据
+//   - publicKey: 接收方公钥（32字节）
+//   - aad: 附加数据（见 EncryptAESWithAAD，可为 nil）
+//
+// 返回值：
+//   - []byte: 加密后的数据（临时公钥32字节 + EncryptAESWithAAD 的输出）
+//   - error: 加密过程中的错误
+func EncryptX25519(plaintext []byte, publicKey []byte, aad []byte) ([]byte, error) {
+	curve := ecdh.X25519()
+	recipient, err := curve.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPublic := ephemeral.PublicKey().Bytes()
+	key, err := deriveX25519Key(shared, ephemeralPublic, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := EncryptAESWithAAD(plaintext, key, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ephemeralPublic, ciphertext...), nil
+}
+
+// DecryptX25519 使用X25519私钥解密 EncryptX25519 加密的数据
+// 参数：
+//   - ciphertext: 加密后的数据
+//   - privateKey: 接收方私钥（32字节）
+//   - aad: 加密时使用的附加数据（可为 nil）
+//
+// 返回值：
+//   - []byte: 解密后的明文数据
+//   - error: 解密过程中的错误
+func DecryptX25519(ciphertext []byte, privateKey []byte, aad []byte) ([]byte, error) {
+	curve := ecdh.X25519()
+	if len(ciphertext) < 32 {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	recipient, err := curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := curve.NewPublicKey(ciphertext[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := recipient.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveX25519Key(shared, ciphertext[:32], recipient.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptAESWithAAD(ciphertext[32:], key, aad)
+}
+
+// deriveX25519Key 由共享密钥派生AES-256密钥，盐为临时公钥和接收方公钥
+func deriveX25519Key(shared, ephemeralPublic, recipientPublic []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeralPublic...), recipientPublic...)
+
+	key := make([]byte, 32)
+	reader := hkdf.New(sha256.New, shared, salt, []byte(x25519KeyInfo))
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestX25519RoundTrip(t *testing.T) {
+	privateKey, publicKey, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+	if len(privateKey) != 32 || len(publicKey) != 32 {
+		t.Fatalf("key lengths = %d/%d, want 32/32", len(privateKey), len(publicKey))
+	}
+
+	derived, err := X25519PublicKey(privateKey)
+	if err != nil {
+		t.Fatalf("X25519PublicKey: %v", err)
+	}
+	if !bytes.Equal(derived, publicKey) {
+		t.Fatal("X25519PublicKey does not match the generated public key")
+	}
+
+	if id := X25519KeyID(publicKey); len(id) != 16 || id != X25519KeyID(derived) {
+		t.Fatalf("X25519KeyID = %q, want a stable 16-digit ID", id)
+	}
+
+	plaintext := []byte("license payload")
+	aad := []byte("header")
+	for _, tc := range []struct {
+		name string
+		aad  []byte
+	}{
+		{"with aad", aad},
+		{"without aad", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ciphertext, err := EncryptX25519(plaintext, publicKey, tc.aad)
+			if err != nil {
+				t.Fatalf("EncryptX25519: %v", err)
+			}
+			got, err := DecryptX25519(ciphertext, privateKey, tc.aad)
+			if err != nil {
+				t.Fatalf("DecryptX25519: %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("DecryptX25519 = %q, want %q", got, plaintext)
+			}
+		})
+	}
+
+	// 每次加密使用新的临时密钥
+	first, err := EncryptX25519(plaintext, publicKey, aad)
+	if err != nil {
+		t.Fatalf("EncryptX25519: %v", err)
+	}
+	second, err := EncryptX25519(plaintext, publicKey, aad)
+	if err != nil {
+		t.Fatalf("EncryptX25519: %v", err)
+	}
+	if bytes.Equal(first[:32], second[:32]) {
+		t.Fatal("EncryptX25519 reused the ephemeral public key")
+	}
+}
+
+func TestX25519Tamper(t *testing.T) {
+	privateKey, publicKey, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+	otherPrivateKey, otherPublicKey, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+
+	aad := []byte("header")
+	ciphertext, err := EncryptX25519([]byte("license payload"), publicKey, aad)
+	if err != nil {
+		t.Fatalf("EncryptX25519: %v", err)
+	}
+
+	flip := func(i int) []byte {
+		data := bytes.Clone(ciphertext)
+		data[i] ^= 0x01
+		return data
+	}
+
+	// 替换临时公钥：使用另一个密钥对的公钥
+	swappedEphemeral := append(bytes.Clone(otherPublicKey), ciphertext[32:]...)
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		privateKey []byte
+		aad        []byte
+	}{
+		{"other device key", ciphertext, otherPrivateKey, aad},
+		{"changed aad", ciphertext, privateKey, []byte("Header")},
+		{"missing aad", ciphertext, privateKey, nil},
+		{"tampered ephemeral key", flip(0), privateKey, aad},
+		{"swapped ephemeral key", swappedEphemeral, privateKey, aad},
+		{"tampered ciphertext", flip(len(ciphertext) - 1), privateKey, aad},
+		{"truncated", ciphertext[:31], privateKey, aad},
+		{"invalid private key", ciphertext, privateKey[:16], aad},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecryptX25519(tc.ciphertext, tc.privateKey, tc.aad); err == nil {
+				t.Fatal("DecryptX25519 succeeded, want error")
+			}
+		})
+	}
+
+	if _, err := EncryptX25519([]byte("license payload"), publicKey[:16], nil); err == nil {
+		t.Fatal("EncryptX25519 with invalid public key succeeded, want error")
+	}
+}
@@ -0,0 +1,163 @@
+// Package database 提供数据库操作功能
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrActivationLimit 表示多设备许可证的激活设备数已达上限
+	ErrActivationLimit = errors.New("activation limit reached")
+
+	// ErrNotActivated 表示设备未激活该许可证
+	ErrNotActivated = errors.New("device not activated for license")
+)
+
+// ActivationRecord 多设备许可证的激活记录
+// 每台激活的设备占用一个激活名额，停用后名额释放
+type ActivationRecord struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`                                         // 主键ID
+	LicenseID   int64     `gorm:"not null;uniqueIndex:idx_activations_license_device" json:"license_id"`      // 许可证记录ID
+	DeviceID    string    `gorm:"not null;uniqueIndex:idx_activations_license_device;index" json:"device_id"` // 设备ID
+	AppID       string    `json:"app_id"`                                                                     // 应用ID
+	ActivatedAt time.Time `gorm:"not null" json:"activated_at"`                                               // 激活时间
+}
+
+// TableName 指定表名
+func (ActivationRecord) TableName() string {
+	return "license_activations"
+}
+
+// ActivateDevice 在多设备许可证上激活设备
+// 同一设备重复激活时返回已有的激活记录；设备记录不存在时创建，并关联到该许可证
+// 参数：
+//   - record: 许可证记录（MaxActivations 为激活上限）
+//   - deviceID: 设备ID
+//   - deviceName: 设备名称（创建设备记录时使用）
+//   - appID: 应用ID
+//
+// 返回值：
+//   - *ActivationRecord: 激活记录
+//   - int64: 激活后已占用的激活名额
+//   - error: 激活数已达上限时返回 ErrActivationLimit
+func (db *DB) ActivateDevice(record *LicenseRecord, deviceID, deviceName, appID string) (*ActivationRecord, int64, error) {
+	var activation ActivationRecord
+	var count int64
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("license_id = ? AND device_id = ?", record.ID, deviceID).First(&activation).Error
+		if err == nil {
+			return tx.Model(&ActivationRecord{}).Where("license_id = ?", record.ID).Count(&count).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Model(&ActivationRecord{}).Where("license_id = ?", record.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(record.MaxActivations) {
+			return ErrActivationLimit
+		}
+
+		now := time.Now()
+		activation = ActivationRecord{
+			LicenseID:   record.ID,
+			DeviceID:    deviceID,
+			AppID:       appID,
+			ActivatedAt: now,
+		}
+		if err := tx.Create(&activation).Error; err != nil {
+			return err
+		}
+		count++
+
+		// 绑定设备记录（设备未注册时创建）
+		var device DeviceRecord
+		err = tx.Where("device_id = ?", deviceID).First(&device).Error
+		switch {
+		case err == nil:
+			return tx.Model(&device).Updates(map[string]interface{}{
+				"license_id": record.ID,
+				"last_seen":  now,
+			}).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(&DeviceRecord{
+				DeviceID:     deviceID,
+				DeviceName:   deviceName,
+				AppID:        appID,
+				LicenseID:    record.ID,
+				Status:       "active",
+				RegisteredAt: now,
+				LastSeen:     now,
+			}).Error
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &activation, count, nil
+}
+
+// GetActivation 获取设备在许可证上的激活记录
+// 参数：
+//   - licenseID: 许可证记录ID
+//   - deviceID: 设备ID
+//
+// 返回值：
+//   - *ActivationRecord: 激活记录
+//   - error: 设备未激活该许可证时返回 ErrNotActivated
+func (db *DB) GetActivation(licenseID int64, deviceID string) (*ActivationRecord, error) {
+	var activation ActivationRecord
+	if err := db.db.Where("license_id = ? AND device_id = ?", licenseID, deviceID).First(&activation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotActivated
+		}
+		return nil, err
+	}
+	return &activation, nil
+}
+
+// DeactivateDevice 停用设备，释放其占用的激活名额
+// 参数：
+//   - licenseID: 许可证记录ID
+//   - deviceID: 设备ID
+//
+// 返回值：
+//   - error: 设备未激活该许可证时返回 ErrNotActivated
+func (db *DB) DeactivateDevice(licenseID int64, deviceID string) error {
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("license_id = ? AND device_id = ?", licenseID, deviceID).Delete(&ActivationRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotActivated
+		}
+
+		return tx.Model(&DeviceRecord{}).
+			Where("device_id = ? AND license_id = ?", deviceID, licenseID).
+			Update("license_id", 0).Error
+	})
+}
+
+// ListActivations 列出许可证的激活记录（按激活时间排序）
+// 参数：
+//   - licenseID: 许可证记录ID
+//
+// 返回值：
+//   - []*ActivationRecord: 激活记录列表
+//   - error: 查询过程中的错误
+func (db *DB) ListActivations(licenseID int64) ([]*ActivationRecord, error) {
+	var activations []*ActivationRecord
+	err := db.db.Where("license_id = ?", licenseID).Order("activated_at ASC").Find(&activations).Error
+	if err != nil {
+		return nil, err
+	}
+	return activations, nil
+}
@@ -3,6 +3,8 @@ package database
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +16,12 @@ import (
 	_ "modernc.org/sqlite" // 纯Go SQLite驱动，无需CGO
 )
 
+// ErrDeviceNotFound 表示设备不存在
+var ErrDeviceNotFound = errors.New("device not found")
+
+// ErrDeviceExists 表示设备已经注册
+var ErrDeviceExists = errors.New("device already registered")
+
 // DB 数据库连接
 type DB struct {
 	db *gorm.DB
@@ -145,6 +153,8 @@ func (db *DB) autoMigrate() error {
 		&DeviceRecord{},
 		&KeyRecord{},
 		&TokenRecord{},
+		&SeatLease{},
+		&ActivationRecord{},
 	)
 }
 
@@ -165,8 +175,8 @@ func (db *DB) SaveLicense(record *LicenseRecord) (int64, error) {
 		record.UpdatedAt = now
 	}
 
-	// 验证必填字段
-	if record.DeviceID == "" {
+	// 验证必填字段（试用许可证可以不绑定设备）
+	if record.DeviceID == "" && record.LicenseType != "trial" {
 		return 0, fmt.Errorf("device_id is required")
 	}
 	if record.LicenseKey == "" {
@@ -175,7 +185,7 @@ func (db *DB) SaveLicense(record *LicenseRecord) (int64, error) {
 	if record.LicenseType == "" {
 		return 0, fmt.Errorf("license_type is required")
 	}
-	if record.ExpiryDate.IsZero() {
+	if record.ExpiryDate.IsZero() && !record.Perpetual {
 		return 0, fmt.Errorf("expiry_date is required")
 	}
 
@@ -199,6 +209,7 @@ func (db *DB) SaveLicense(record *LicenseRecord) (int64, error) {
 }
 
 // GetLicenseByDeviceID 根据设备ID获取许可证
+// 同一设备存在多个许可证时返回最新签发的许可证
 // 参数：
 //   - deviceID: 设备ID
 //
@@ -207,7 +218,12 @@ func (db *DB) SaveLicense(record *LicenseRecord) (int64, error) {
 //   - error: 查询过程中的错误
 func (db *DB) GetLicenseByDeviceID(deviceID string) (*LicenseRecord, error) {
 	var record LicenseRecord
-	if err := db.db.Where("device_id = ?", deviceID).First(&record).Error; err != nil {
+	// 多设备许可证通过激活记录关联到设备，试用许可证通过设备的试用记录关联
+	err := db.db.Where("device_id = ?", deviceID).
+		Or("id IN (?)", db.db.Model(&ActivationRecord{}).Select("license_id").Where("device_id = ?", deviceID)).
+		Or("license_id IN (?)", db.db.Model(&DeviceRecord{}).Select("trial_license_id").Where("device_id = ? AND trial_license_id <> ''", deviceID)).
+		Order("id DESC").First(&record).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("license not found for device: %s", deviceID)
 		}
@@ -223,12 +239,20 @@ func (db *DB) GetLicenseByDeviceID(deviceID string) (*LicenseRecord, error) {
 //
 // 返回值：
 //   - int64: 插入的记录ID
-//   - error: 保存过程中的错误
+//   - error: 保存过程中的错误，设备已注册时返回 ErrDeviceExists
 func (db *DB) SaveDevice(record *DeviceRecord) (int64, error) {
 	now := time.Now()
 	record.RegisteredAt = now
 	record.LastSeen = now
 
+	var count int64
+	if err := db.db.Model(&DeviceRecord{}).Where("device_id = ?", record.DeviceID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrDeviceExists, record.DeviceID)
+	}
+
 	if err := db.db.Create(record).Error; err != nil {
 		return 0, err
 	}
@@ -247,7 +271,7 @@ func (db *DB) GetDeviceByID(deviceID string) (*DeviceRecord, error) {
 	var record DeviceRecord
 	if err := db.db.Where("device_id = ?", deviceID).First(&record).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("device not found: %s", deviceID)
+			return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, deviceID)
 		}
 		return nil, err
 	}
@@ -255,6 +279,32 @@ func (db *DB) GetDeviceByID(deviceID string) (*DeviceRecord, error) {
 	return &record, nil
 }
 
+// GetDevicePublicKey 获取设备注册时提交的X25519公钥
+// 参数：
+//   - deviceID: 设备ID
+//
+// 返回值：
+//   - []byte: 设备公钥（32字节），设备不存在或未提交公钥时返回 nil
+//   - error: 查询过程中的错误
+func (db *DB) GetDevicePublicKey(deviceID string) ([]byte, error) {
+	record, err := db.GetDeviceByID(deviceID)
+	if errors.Is(err, ErrDeviceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.PublicKey == "" {
+		return nil, nil
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(record.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key for device %s: %w", deviceID, err)
+	}
+	return publicKey, nil
+}
+
 // ListDevices 列出所有设备
 // 参数：
 //   - limit: 限制数量
@@ -308,6 +358,13 @@ func (db *DB) GetStats() (map[string]int64, error) {
 	}
 	stats["expired_licenses"] = expiredLicenses
 
+	// 已撤销许可证数
+	var revokedLicenses int64
+	if err := db.db.Model(&LicenseRecord{}).Where("revoked_at IS NOT NULL").Count(&revokedLicenses).Error; err != nil {
+		return nil, err
+	}
+	stats["revoked_licenses"] = revokedLicenses
+
 	return stats, nil
 }
 
@@ -347,6 +404,25 @@ func (db *DB) GetLicenseByID(id int64) (*LicenseRecord, error) {
 	return &record, nil
 }
 
+// GetLicenseByLicenseID 根据嵌入许可证中的许可证ID获取许可证记录
+// 参数：
+//   - licenseID: 许可证ID（UUID）
+//
+// 返回值：
+//   - *LicenseRecord: 许可证记录
+//   - error: 查询过程中的错误
+func (db *DB) GetLicenseByLicenseID(licenseID string) (*LicenseRecord, error) {
+	var record LicenseRecord
+	if err := db.db.Where("license_id = ? AND license_id <> ''", licenseID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("license not found: %s", licenseID)
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
 // DeleteLicense 删除许可证（软删除）
 // 参数：
 //   - id: 许可证ID
@@ -357,6 +433,65 @@ func (db *DB) DeleteLicense(id int64) error {
 	return db.db.Delete(&LicenseRecord{}, id).Error
 }
 
+// RevokeLicense 撤销许可证
+// 撤销后网络验证和双重验证将返回 LICENSE_REVOKED
+// 参数：
+//   - id: 许可证ID
+//   - reason: 撤销原因
+//   - revokedBy: 撤销操作人
+//
+// 返回值：
+//   - error: 撤销过程中的错误
+func (db *DB) RevokeLicense(id int64, reason, revokedBy string) error {
+	now := time.Now()
+	result := db.db.Model(&LicenseRecord{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"revoked_at":    &now,
+		"revoke_reason": reason,
+		"revoked_by":    revokedBy,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke license: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("license not found: %d", id)
+	}
+	return nil
+}
+
+// UnrevokeLicense 恢复已撤销的许可证
+// 参数：
+//   - id: 许可证ID
+//
+// 返回值：
+//   - error: 恢复过程中的错误
+func (db *DB) UnrevokeLicense(id int64) error {
+	result := db.db.Model(&LicenseRecord{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"revoked_at":    nil,
+		"revoke_reason": "",
+		"revoked_by":    "",
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unrevoke license: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("license not found: %d", id)
+	}
+	return nil
+}
+
+// ListRevokedLicenses 列出所有已撤销的许可证（用于生成吊销列表）
+// 返回值：
+//   - []*LicenseRecord: 已撤销的许可证记录
+//   - error: 查询过程中的错误
+func (db *DB) ListRevokedLicenses() ([]*LicenseRecord, error) {
+	var licenses []*LicenseRecord
+	if err := db.db.Where("revoked_at IS NOT NULL").Order("revoked_at ASC").Find(&licenses).Error; err != nil {
+		return nil, err
+	}
+
+	return licenses, nil
+}
+
 // UpdateDeviceStatus 更新设备状态
 // 参数：
 //   - deviceID: 设备ID
@@ -368,6 +503,25 @@ func (db *DB) UpdateDeviceStatus(deviceID, status string) error {
 	return db.db.Model(&DeviceRecord{}).Where("device_id = ?", deviceID).Update("status", status).Error
 }
 
+// UpdateDevicePublicKey 更换设备登记的X25519公钥（设备丢失私钥后由管理员执行）
+// 之后签发给该设备的许可证加密到新公钥，已签发的许可证需要重新签发
+// 参数：
+//   - deviceID: 设备ID
+//   - publicKey: 新的设备公钥（base64，为空时清除公钥）
+//
+// 返回值：
+//   - error: 设备不存在时返回 ErrDeviceNotFound
+func (db *DB) UpdateDevicePublicKey(deviceID, publicKey string) error {
+	result := db.db.Model(&DeviceRecord{}).Where("device_id = ?", deviceID).Update("public_key", publicKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrDeviceNotFound, deviceID)
+	}
+	return nil
+}
+
 // GetDeviceCount 获取设备总数
 // 返回值：
 //   - int64: 设备总数
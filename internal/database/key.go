@@ -0,0 +1,138 @@
+// Package database 提供数据库操作功能
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 密钥类型
+const (
+	KeyTypeMaster  = "master"  // 主密钥参数（口令派生参数和校验值，不含密钥本身）
+	KeyTypeSigning = "signing" // 许可证签名密钥
+	KeyTypeAES     = "aes"     // 许可证加密密钥
+)
+
+// 密钥状态
+const (
+	KeyStatusActive   = "active"   // 当前用于签名
+	KeyStatusRetired  = "retired"  // 已退役，仅用于验证已签发的许可证
+	KeyStatusExternal = "external" // 私钥保存在外部签名设备（HSM）中，仅记录公钥
+)
+
+// ErrKeyNotFound 表示密钥不存在
+var ErrKeyNotFound = errors.New("key not found")
+
+// SaveKey 保存密钥记录
+// 参数：
+//   - record: 密钥记录
+//
+// 返回值：
+//   - error: 保存过程中的错误
+func (db *DB) SaveKey(record *KeyRecord) error {
+	record.CreatedAt = time.Now()
+	if record.Status == "" {
+		record.Status = KeyStatusActive
+	}
+
+	return db.db.Create(record).Error
+}
+
+// GetActiveKey 获取指定类型的激活密钥
+// 参数：
+//   - keyType: 密钥类型
+//
+// 返回值：
+//   - *KeyRecord: 密钥记录
+//   - error: 没有激活密钥时返回 ErrKeyNotFound
+func (db *DB) GetActiveKey(keyType string) (*KeyRecord, error) {
+	var record KeyRecord
+	err := db.db.Where("key_type = ? AND status = ?", keyType, KeyStatusActive).
+		Order("id DESC").First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// GetKeyByKeyID 根据密钥ID获取密钥
+// 参数：
+//   - keyType: 密钥类型
+//   - keyID: 密钥ID
+//
+// 返回值：
+//   - *KeyRecord: 密钥记录
+//   - error: 密钥不存在时返回 ErrKeyNotFound
+func (db *DB) GetKeyByKeyID(keyType, keyID string) (*KeyRecord, error) {
+	var record KeyRecord
+	err := db.db.Where("key_type = ? AND key_id = ?", keyType, keyID).First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// ListKeys 列出指定类型的所有密钥（激活和已退役）
+// 参数：
+//   - keyType: 密钥类型
+//
+// 返回值：
+//   - []*KeyRecord: 密钥记录列表（按创建顺序）
+//   - error: 查询过程中的错误
+func (db *DB) ListKeys(keyType string) ([]*KeyRecord, error) {
+	var records []*KeyRecord
+	if err := db.db.Where("key_type = ?", keyType).Order("id ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// RotateKey 轮换密钥：将当前激活的同类型密钥标记为退役，并保存新的激活密钥
+// 参数：
+//   - record: 新密钥记录
+//
+// 返回值：
+//   - error: 轮换过程中的错误
+func (db *DB) RotateKey(record *KeyRecord) error {
+	return db.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&KeyRecord{}).
+			Where("key_type = ? AND status = ?", record.KeyType, KeyStatusActive).
+			Updates(map[string]interface{}{
+				"status":     KeyStatusRetired,
+				"retired_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		record.CreatedAt = now
+		record.Status = KeyStatusActive
+		return tx.Create(record).Error
+	})
+}
+
+// UpdateKeyData 更新密钥数据（例如将明文密钥加密保存）
+// 参数：
+//   - record: 密钥记录（使用 ID、KeyData 和 Encrypted 字段）
+//
+// 返回值：
+//   - error: 更新过程中的错误
+func (db *DB) UpdateKeyData(record *KeyRecord) error {
+	return db.db.Model(&KeyRecord{}).Where("id = ?", record.ID).
+		Updates(map[string]interface{}{
+			"key_data":  record.KeyData,
+			"encrypted": record.Encrypted,
+		}).Error
+}
@@ -9,14 +9,29 @@ import (
 
 // LicenseRecord 许可证记录
 type LicenseRecord struct {
-	ID          int64          `gorm:"primaryKey;autoIncrement" json:"id"` // 主键ID
-	DeviceID    string         `gorm:"not null;index" json:"device_id"`    // 设备ID
-	LicenseKey  string         `gorm:"not null" json:"license_key"`        // 许可证密钥
-	LicenseType string         `gorm:"not null" json:"license_type"`       // 许可证类型
-	ExpiryDate  time.Time      `gorm:"not null" json:"expiry_date"`        // 到期时间
-	CreatedAt   time.Time      `json:"created_at"`                         // 创建时间
-	UpdatedAt   time.Time      `json:"updated_at"`                         // 更新时间
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`                     // 软删除（不序列化）
+	ID               int64          `gorm:"primaryKey;autoIncrement" json:"id"`                                           // 主键ID
+	LicenseID        string         `gorm:"uniqueIndex:idx_licenses_license_id,where:license_id <> ''" json:"license_id"` // 许可证ID（嵌入签名数据中，旧版许可证为空）
+	DeviceID         string         `gorm:"not null;index" json:"device_id"`                                              // 设备ID
+	ProductID        string         `gorm:"index" json:"product_id"`                                                      // 产品ID（写入许可证，加密的许可证使用该产品的派生密钥加密）
+	AppID            string         `gorm:"index" json:"app_id"`                                                          // 所属应用ID（客户端Token只能访问所属应用的许可证）
+	VersionRange     string         `json:"version_range"`                                                                // 适用的产品版本范围（为空表示不限版本）
+	LicenseKey       string         `gorm:"not null" json:"license_key"`                                                  // 许可证密钥
+	LicenseType      string         `gorm:"not null" json:"license_type"`                                                 // 许可证类型
+	Seats            int            `json:"seats"`                                                                        // 并发席位数（仅浮动许可证）
+	MaxActivations   int            `json:"max_activations"`                                                              // 最大激活设备数（多设备许可证，见 ActivationRecord）
+	TrialDays        int            `json:"trial_days"`                                                                   // 试用天数（仅试用许可证，从设备首次激活开始计算）
+	Features         string         `json:"features"`                                                                     // 功能列表（逗号分隔）
+	Entitlements     string         `json:"entitlements"`                                                                 // 授权项（JSON，与许可证中的授权项一致）
+	ExpiryDate       time.Time      `gorm:"not null" json:"expiry_date"`                                                  // 到期时间（永久许可证为零值）
+	NotBefore        time.Time      `json:"not_before"`                                                                   // 生效时间（零值表示签发后立即生效）
+	Perpetual        bool           `json:"perpetual"`                                                                    // 永久许可证（不过期）
+	MaintenanceUntil time.Time      `json:"maintenance_until"`                                                            // 维护截止日期（零值表示不限制）
+	RevokedAt        *time.Time     `gorm:"index" json:"revoked_at"`                                                      // 撤销时间（NULL表示未撤销）
+	RevokeReason     string         `json:"revoke_reason"`                                                                // 撤销原因
+	RevokedBy        string         `json:"revoked_by"`                                                                   // 撤销操作人
+	CreatedAt        time.Time      `json:"created_at"`                                                                   // 创建时间
+	UpdatedAt        time.Time      `json:"updated_at"`                                                                   // 更新时间
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`                                                               // 软删除（不序列化）
 }
 
 // TableName 指定表名
@@ -24,17 +39,35 @@ func (LicenseRecord) TableName() string {
 	return "licenses"
 }
 
+// IsRevoked 判断许可证是否已被撤销
+func (r *LicenseRecord) IsRevoked() bool {
+	return r.RevokedAt != nil
+}
+
+// IsExpired 判断许可证在指定时间是否已过期（永久许可证不过期）
+func (r *LicenseRecord) IsExpired(now time.Time) bool {
+	return !r.Perpetual && now.After(r.ExpiryDate)
+}
+
+// IsNotYetValid 判断许可证在指定时间是否尚未生效
+func (r *LicenseRecord) IsNotYetValid(now time.Time) bool {
+	return !r.NotBefore.IsZero() && now.Before(r.NotBefore)
+}
+
 // DeviceRecord 设备记录
 type DeviceRecord struct {
-	ID           int64          `gorm:"primaryKey;autoIncrement" json:"id"`    // 主键ID
-	DeviceID     string         `gorm:"uniqueIndex;not null" json:"device_id"` // 设备ID（唯一）
-	DeviceName   string         `json:"device_name"`                           // 设备名称
-	AppID        string         `json:"app_id"`                                // 应用ID
-	LicenseID    int64          `json:"license_id"`                            // 关联的许可证ID
-	Status       string         `gorm:"default:active;index" json:"status"`    // 状态（active, expired, revoked）
-	RegisteredAt time.Time      `gorm:"not null" json:"registered_at"`         // 注册时间
-	LastSeen     time.Time      `gorm:"not null" json:"last_seen"`             // 最后访问时间
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`                        // 软删除（不序列化）
+	ID             int64          `gorm:"primaryKey;autoIncrement" json:"id"`    // 主键ID
+	DeviceID       string         `gorm:"uniqueIndex;not null" json:"device_id"` // 设备ID（唯一）
+	DeviceName     string         `json:"device_name"`                           // 设备名称
+	AppID          string         `json:"app_id"`                                // 应用ID
+	PublicKey      string         `json:"public_key"`                            // 设备X25519公钥（base64，许可证加密到该公钥）
+	LicenseID      int64          `json:"license_id"`                            // 关联的许可证ID
+	TrialLicenseID string         `gorm:"index" json:"trial_license_id"`         // 已试用的试用许可证ID（每台设备只能试用一次）
+	TrialStartedAt *time.Time     `json:"trial_started_at"`                      // 开始试用时间（NULL表示未试用）
+	Status         string         `gorm:"default:active;index" json:"status"`    // 状态（active, expired, revoked）
+	RegisteredAt   time.Time      `gorm:"not null" json:"registered_at"`         // 注册时间
+	LastSeen       time.Time      `gorm:"not null" json:"last_seen"`             // 最后访问时间
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`                        // 软删除（不序列化）
 }
 
 // TableName 指定表名
@@ -44,11 +77,17 @@ func (DeviceRecord) TableName() string {
 
 // KeyRecord 密钥记录
 type KeyRecord struct {
-	ID        int64          `gorm:"primaryKey;autoIncrement" json:"id"` // 主键ID
-	KeyType   string         `gorm:"not null" json:"key_type"`           // 密钥类型（aes, rsa_private, rsa_public）
-	KeyData   []byte         `gorm:"not null" json:"-"`                  // 密钥数据（不序列化，安全考虑）
-	CreatedAt time.Time      `gorm:"not null" json:"created_at"`         // 创建时间
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                     // 软删除（不序列化）
+	ID        int64          `gorm:"primaryKey;autoIncrement" json:"id"`      // 主键ID
+	KeyID     string         `gorm:"index" json:"key_id"`                     // 密钥ID（签名密钥为公钥指纹）
+	KeyType   string         `gorm:"not null" json:"key_type"`                // 密钥类型（master, signing, aes）
+	Algorithm string         `json:"algorithm"`                               // 签名算法（rsa, ed25519）
+	Status    string         `gorm:"default:active;index" json:"status"`      // 状态（active, retired, external）
+	KeyData   []byte         `gorm:"not null" json:"-"`                       // 密钥数据（不序列化，安全考虑）
+	Encrypted bool           `gorm:"not null;default:false" json:"encrypted"` // 密钥数据是否已用主密钥加密
+	PublicKey string         `json:"public_key"`                              // PEM编码的公钥（签名密钥）
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`              // 创建时间
+	RetiredAt *time.Time     `json:"retired_at"`                              // 退役时间（NULL表示未退役）
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                          // 软删除（不序列化）
 }
 
 // TableName 指定表名
@@ -0,0 +1,173 @@
+// Package database 提供数据库操作功能
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNoSeatsAvailable 表示浮动许可证的席位已全部被占用
+	ErrNoSeatsAvailable = errors.New("no seats available")
+
+	// ErrLeaseNotFound 表示席位租约不存在或已过期（席位已被回收）
+	ErrLeaseNotFound = errors.New("seat lease not found or expired")
+)
+
+// SeatLease 浮动许可证的席位租约
+// 客户端签出席位后定期发送心跳续期，超过到期时间未续期的租约视为失效，席位被回收
+// 时间统一使用UTC保存，查询时按保存的文本比较到期时间
+type SeatLease struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`   // 主键ID
+	LeaseID      string    `gorm:"uniqueIndex;not null" json:"lease_id"` // 租约ID（UUID）
+	LicenseID    string    `gorm:"not null;index" json:"license_id"`     // 许可证ID
+	DeviceID     string    `gorm:"not null" json:"device_id"`            // 占用席位的客户端设备ID
+	AppID        string    `json:"app_id"`                               // 应用ID
+	CheckedOutAt time.Time `gorm:"not null" json:"checked_out_at"`       // 签出时间
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`     // 租约到期时间（心跳时续期）
+}
+
+// TableName 指定表名
+func (SeatLease) TableName() string {
+	return "seat_leases"
+}
+
+// CheckoutSeat 签出浮动许可证的席位
+// 先回收已过期的租约；同一设备重复签出时续期并返回已有租约
+// 参数：
+//   - licenseID: 许可证ID
+//   - deviceID: 客户端设备ID
+//   - appID: 应用ID
+//   - seats: 许可证的席位数
+//   - ttl: 租约有效期
+//
+// 返回值：
+//   - *SeatLease: 席位租约
+//   - int64: 签出后占用的席位数
+//   - error: 席位已满时返回 ErrNoSeatsAvailable
+func (db *DB) CheckoutSeat(licenseID, deviceID, appID string, seats int, ttl time.Duration) (*SeatLease, int64, error) {
+	var lease SeatLease
+	var inUse int64
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.Where("license_id = ? AND expires_at <= ?", licenseID, now).Delete(&SeatLease{}).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("license_id = ? AND device_id = ?", licenseID, deviceID).First(&lease).Error
+		switch {
+		case err == nil:
+			lease.ExpiresAt = now.Add(ttl)
+			if err := tx.Model(&lease).Update("expires_at", lease.ExpiresAt).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Model(&SeatLease{}).Where("license_id = ?", licenseID).Count(&inUse).Error; err != nil {
+				return err
+			}
+			if inUse >= int64(seats) {
+				return ErrNoSeatsAvailable
+			}
+			lease = SeatLease{
+				LeaseID:      uuid.NewString(),
+				LicenseID:    licenseID,
+				DeviceID:     deviceID,
+				AppID:        appID,
+				CheckedOutAt: now,
+				ExpiresAt:    now.Add(ttl),
+			}
+			if err := tx.Create(&lease).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Model(&SeatLease{}).Where("license_id = ?", licenseID).Count(&inUse).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &lease, inUse, nil
+}
+
+// RenewSeat 续期席位租约（心跳）
+// 参数：
+//   - leaseID: 租约ID
+//   - ttl: 租约有效期
+//
+// 返回值：
+//   - *SeatLease: 续期后的租约
+//   - error: 租约不存在或已过期时返回 ErrLeaseNotFound
+func (db *DB) RenewSeat(leaseID string, ttl time.Duration) (*SeatLease, error) {
+	var lease SeatLease
+	now := time.Now().UTC()
+	if err := db.db.Where("lease_id = ? AND expires_at > ?", leaseID, now).First(&lease).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeaseNotFound
+		}
+		return nil, err
+	}
+
+	lease.ExpiresAt = now.Add(ttl)
+	if err := db.db.Model(&lease).Update("expires_at", lease.ExpiresAt).Error; err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// GetSeatLease 根据租约ID获取未过期的席位租约
+// 参数：
+//   - leaseID: 租约ID
+//
+// 返回值：
+//   - *SeatLease: 席位租约
+//   - error: 租约不存在或已过期时返回 ErrLeaseNotFound
+func (db *DB) GetSeatLease(leaseID string) (*SeatLease, error) {
+	var lease SeatLease
+	if err := db.db.Where("lease_id = ? AND expires_at > ?", leaseID, time.Now().UTC()).First(&lease).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeaseNotFound
+		}
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// ReleaseSeat 释放席位租约（签入）
+// 参数：
+//   - leaseID: 租约ID
+//
+// 返回值：
+//   - error: 租约不存在时返回 ErrLeaseNotFound
+func (db *DB) ReleaseSeat(leaseID string) error {
+	result := db.db.Where("lease_id = ?", leaseID).Delete(&SeatLease{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// ListSeatLeases 列出许可证当前未过期的席位租约
+// 参数：
+//   - licenseID: 许可证ID
+//
+// 返回值：
+//   - []*SeatLease: 席位租约列表
+//   - error: 查询过程中的错误
+func (db *DB) ListSeatLeases(licenseID string) ([]*SeatLease, error) {
+	var leases []*SeatLease
+	err := db.db.Where("license_id = ? AND expires_at > ?", licenseID, time.Now().UTC()).
+		Order("checked_out_at ASC").Find(&leases).Error
+	if err != nil {
+		return nil, err
+	}
+	return leases, nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestDB 在临时目录中创建数据库
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewDB(filepath.Join(t.TempDir(), "license.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCheckoutSeat(t *testing.T) {
+	db := newTestDB(t)
+	const seats = 2
+	ttl := time.Hour
+
+	// 每一步签出后的期望结果
+	steps := []struct {
+		licenseID string
+		deviceID  string
+		wantInUse int64
+		wantErr   error
+	}{
+		{"lic-1", "device-a", 1, nil},
+		{"lic-1", "device-b", 2, nil},
+		{"lic-1", "device-c", 0, ErrNoSeatsAvailable},
+		{"lic-1", "device-a", 2, nil}, // 同一设备重复签出时续期已有租约
+		{"lic-2", "device-c", 1, nil}, // 席位按许可证分别计算
+	}
+	leases := make(map[string]*SeatLease)
+	for i, step := range steps {
+		lease, inUse, err := db.CheckoutSeat(step.licenseID, step.deviceID, "app", seats, ttl)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("step %d: CheckoutSeat(%s, %s): err = %v, want %v", i, step.licenseID, step.deviceID, err, step.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if inUse != step.wantInUse {
+			t.Fatalf("step %d: CheckoutSeat(%s, %s) in use = %d, want %d", i, step.licenseID, step.deviceID, inUse, step.wantInUse)
+		}
+		key := step.licenseID + "/" + step.deviceID
+		if previous, ok := leases[key]; ok && previous.LeaseID != lease.LeaseID {
+			t.Fatalf("step %d: repeated checkout returned a new lease %s, want %s", i, lease.LeaseID, previous.LeaseID)
+		}
+		leases[key] = lease
+	}
+
+	// 签入后席位可以被其他设备签出
+	if err := db.ReleaseSeat(leases["lic-1/device-b"].LeaseID); err != nil {
+		t.Fatalf("ReleaseSeat: %v", err)
+	}
+	if err := db.ReleaseSeat(leases["lic-1/device-b"].LeaseID); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("ReleaseSeat twice: err = %v, want ErrLeaseNotFound", err)
+	}
+	if _, inUse, err := db.CheckoutSeat("lic-1", "device-c", "app", seats, ttl); err != nil || inUse != 2 {
+		t.Fatalf("CheckoutSeat after release = %d, %v; want 2, nil", inUse, err)
+	}
+
+	active, err := db.ListSeatLeases("lic-1")
+	if err != nil {
+		t.Fatalf("ListSeatLeases: %v", err)
+	}
+	if len(active) != 2 {
+		t.Fatalf("ListSeatLeases returned %d leases, want 2", len(active))
+	}
+}
+
+func TestSeatLeaseExpiry(t *testing.T) {
+	db := newTestDB(t)
+	const seats = 1
+
+	lease, _, err := db.CheckoutSeat("lic-1", "device-a", "app", seats, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("CheckoutSeat: %v", err)
+	}
+	if _, _, err := db.CheckoutSeat("lic-1", "device-b", "app", seats, time.Hour); !errors.Is(err, ErrNoSeatsAvailable) {
+		t.Fatalf("CheckoutSeat with full seats: err = %v, want ErrNoSeatsAvailable", err)
+	}
+
+	// 心跳续期
+	if _, err := db.RenewSeat(lease.LeaseID, 50*time.Millisecond); err != nil {
+		t.Fatalf("RenewSeat: %v", err)
+	}
+	if _, err := db.GetSeatLease(lease.LeaseID); err != nil {
+		t.Fatalf("GetSeatLease: %v", err)
+	}
+
+	// 未续期的租约过期后席位被回收
+	time.Sleep(100 * time.Millisecond)
+	if _, err := db.GetSeatLease(lease.LeaseID); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("GetSeatLease after expiry: err = %v, want ErrLeaseNotFound", err)
+	}
+	if _, err := db.RenewSeat(lease.LeaseID, time.Hour); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("RenewSeat after expiry: err = %v, want ErrLeaseNotFound", err)
+	}
+	if _, inUse, err := db.CheckoutSeat("lic-1", "device-b", "app", seats, time.Hour); err != nil || inUse != 1 {
+		t.Fatalf("CheckoutSeat after expiry = %d, %v; want 1, nil", inUse, err)
+	}
+}
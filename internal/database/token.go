@@ -2,12 +2,15 @@
 package database
 
 import (
-	"fmt"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrTokenNotFound 表示Token不存在
+var ErrTokenNotFound = errors.New("token not found")
+
 // TokenRecord Token记录
 type TokenRecord struct {
 	ID        int64          `gorm:"primaryKey;autoIncrement" json:"id"`        // 主键ID
@@ -54,7 +57,7 @@ func (db *DB) GetToken(token string) (*TokenRecord, error) {
 	var record TokenRecord
 	if err := db.db.Where("token = ?", token).First(&record).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("token not found")
+			return nil, ErrTokenNotFound
 		}
 		return nil, err
 	}
@@ -0,0 +1,64 @@
+// Package database 提供数据库操作功能
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrTrialUsed 表示设备已经试用过其他试用许可证（每台设备只能试用一次）
+var ErrTrialUsed = errors.New("trial already used on this device")
+
+// StartTrial 记录设备开始试用
+// 设备记录不存在时创建；同一试用许可证重复调用时返回已有记录（首次激活时间不变）
+// 参数：
+//   - record: 试用许可证记录
+//   - deviceID: 设备ID（硬件指纹）
+//   - deviceName: 设备名称（创建设备记录时使用）
+//   - appID: 应用ID
+//
+// 返回值：
+//   - *DeviceRecord: 设备记录（TrialStartedAt 为首次激活时间）
+//   - error: 设备已试用过其他许可证时返回 ErrTrialUsed
+func (db *DB) StartTrial(record *LicenseRecord, deviceID, deviceName, appID string) (*DeviceRecord, error) {
+	var device DeviceRecord
+	err := db.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Where("device_id = ?", deviceID).First(&device).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			device = DeviceRecord{
+				DeviceID:       deviceID,
+				DeviceName:     deviceName,
+				AppID:          appID,
+				TrialLicenseID: record.LicenseID,
+				TrialStartedAt: &now,
+				Status:         "active",
+				RegisteredAt:   now,
+				LastSeen:       now,
+			}
+			return tx.Create(&device).Error
+		case err != nil:
+			return err
+		case device.TrialLicenseID == record.LicenseID:
+			return nil
+		case device.TrialLicenseID != "":
+			return ErrTrialUsed
+		}
+
+		device.TrialLicenseID = record.LicenseID
+		device.TrialStartedAt = &now
+		return tx.Model(&device).Updates(map[string]interface{}{
+			"trial_license_id": device.TrialLicenseID,
+			"trial_started_at": now,
+			"last_seen":        now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
@@ -0,0 +1,80 @@
+// Package hsm 提供基于 PKCS#11 设备（HSM）的签名器
+// 私钥保存在设备中，签名操作由设备完成，私钥不会进入 license manager 进程。
+// PKCS#11 支持需要 cgo，使用 -tags pkcs11 构建；默认构建中 Open 返回 ErrNotSupported
+package hsm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// PINEnv 提供 PKCS#11 用户PIN的环境变量
+const PINEnv = "LICENSEMANAGER_PKCS11_PIN"
+
+// ErrNotSupported 表示当前构建不支持 PKCS#11
+var ErrNotSupported = errors.New("PKCS#11 support not compiled in (rebuild with -tags pkcs11)")
+
+// Config PKCS#11 签名设备配置
+type Config struct {
+	Module     string `yaml:"module"`      // PKCS#11 模块路径（例如 /usr/lib/softhsm/libsofthsm2.so）
+	TokenLabel string `yaml:"token_label"` // 令牌标签
+	KeyLabel   string `yaml:"key_label"`   // 签名密钥标签
+	PINFile    string `yaml:"pin_file"`    // 用户PIN文件（为空时读取环境变量 LICENSEMANAGER_PKCS11_PIN）
+}
+
+// Enabled 返回是否配置了 PKCS#11 设备
+func (c Config) Enabled() bool {
+	return c.Module != ""
+}
+
+// String 返回密钥的 PKCS#11 URI（不含PIN），记录在密钥环中用于识别外部密钥
+func (c Config) String() string {
+	return fmt.Sprintf("pkcs11:token=%s;object=%s", c.TokenLabel, c.KeyLabel)
+}
+
+// validate 校验配置
+func (c Config) validate() error {
+	if c.Module == "" {
+		return errors.New("PKCS#11 module path is required")
+	}
+	if c.TokenLabel == "" {
+		return errors.New("PKCS#11 token label is required")
+	}
+	if c.KeyLabel == "" {
+		return errors.New("PKCS#11 key label is required")
+	}
+	return nil
+}
+
+// pin 读取用户PIN（PIN文件优先，其次环境变量）
+func (c Config) pin() (string, error) {
+	if c.PINFile != "" {
+		data, err := os.ReadFile(c.PINFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read PKCS#11 PIN file: %w", err)
+		}
+		return string(bytes.TrimRight(data, "\r\n")), nil
+	}
+
+	if pin := os.Getenv(PINEnv); pin != "" {
+		return pin, nil
+	}
+	return "", fmt.Errorf("PKCS#11 PIN required (use a PIN file or %s)", PINEnv)
+}
+
+// Signer PKCS#11 设备中的签名密钥，使用完毕后需要调用 Close 释放会话
+type Signer struct {
+	crypto.Signer
+	close func() error
+}
+
+// Close 关闭与设备的会话
+// 返回值：
+//   - error: 关闭过程中的错误
+func (s *Signer) Close() error {
+	return s.close()
+}
@@ -0,0 +1,55 @@
+//go:build pkcs11
+
+// Package hsm 提供基于 PKCS#11 设备（HSM）的签名器
+package hsm
+
+import (
+	"fmt"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// Open 打开 PKCS#11 设备并查找签名密钥
+// 参数：
+//   - config: 设备配置
+//
+// 返回值：
+//   - *Signer: 签名器（使用完毕后调用 Close）
+//   - error: 打开设备或查找密钥失败时的错误
+func Open(config Config) (*Signer, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	pin, err := config.pin()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       config.Module,
+		TokenLabel: config.TokenLabel,
+		Pin:        pin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PKCS#11 token %q: %w", config.TokenLabel, err)
+	}
+
+	key, err := ctx.FindKeyPair(nil, []byte(config.KeyLabel))
+	if err == nil && key == nil {
+		err = fmt.Errorf("key not found")
+	}
+	if err != nil {
+		ctx.Close()
+		return nil, fmt.Errorf("failed to find PKCS#11 key %q: %w", config.KeyLabel, err)
+	}
+
+	signer, err := crypto.NewSigner(key)
+	if err != nil {
+		ctx.Close()
+		return nil, err
+	}
+
+	return &Signer{Signer: signer, close: ctx.Close}, nil
+}
@@ -0,0 +1,16 @@
+//go:build !pkcs11
+
+// Package hsm 提供基于 PKCS#11 设备（HSM）的签名器
+package hsm
+
+// Open 打开 PKCS#11 设备并查找签名密钥
+// 当前构建不包含 PKCS#11 支持，始终返回 ErrNotSupported
+// 参数：
+//   - config: 设备配置
+//
+// 返回值：
+//   - *Signer: 始终为 nil
+//   - error: ErrNotSupported
+func Open(config Config) (*Signer, error) {
+	return nil, ErrNotSupported
+}
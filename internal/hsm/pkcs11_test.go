@@ -0,0 +1,112 @@
+//go:build pkcs11
+
+package hsm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// 测试使用的 SoftHSM 令牌，可通过环境变量覆盖：
+// SOFTHSM2_MODULE（模块路径）、SOFTHSM2_TOKEN（令牌标签）、LICENSEMANAGER_PKCS11_PIN（用户PIN）
+const (
+	testModule = "/usr/lib/softhsm/libsofthsm2.so"
+	testToken  = "license"
+	testPIN    = "1234"
+)
+
+// envOr 返回环境变量的值，未设置时返回默认值
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newTestKey 在 SoftHSM 令牌中生成临时的RSA签名密钥，测试结束后删除
+// 令牌需要预先初始化，例如：softhsm2-util --init-token --free --label license --pin 1234 --so-pin 5678
+func newTestKey(t *testing.T) Config {
+	t.Helper()
+	if os.Getenv("SOFTHSM2_CONF") == "" {
+		t.Skip("SOFTHSM2_CONF not set, skipping SoftHSM test")
+	}
+
+	config := Config{
+		Module:     envOr("SOFTHSM2_MODULE", testModule),
+		TokenLabel: envOr("SOFTHSM2_TOKEN", testToken),
+	}
+	pin := envOr(PINEnv, testPIN)
+	t.Setenv(PINEnv, pin)
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	config.KeyLabel = "licensemanager-test-" + hex.EncodeToString(id)
+
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       config.Module,
+		TokenLabel: config.TokenLabel,
+		Pin:        pin,
+	})
+	if err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	t.Cleanup(func() { ctx.Close() })
+
+	key, err := ctx.GenerateRSAKeyPairWithLabel(id, []byte(config.KeyLabel), 2048)
+	if err != nil {
+		t.Fatalf("GenerateRSAKeyPairWithLabel: %v", err)
+	}
+	t.Cleanup(func() { key.Delete() })
+
+	return config
+}
+
+func TestSoftHSMSignVerify(t *testing.T) {
+	config := newTestKey(t)
+
+	signer, err := Open(config)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer signer.Close()
+
+	if signer.Algorithm() != crypto.AlgorithmRSA {
+		t.Fatalf("Algorithm = %s, want %s", signer.Algorithm(), crypto.AlgorithmRSA)
+	}
+
+	// 设备签名使用软件验证器验证（与客户端验证许可证的方式一致）
+	publicKeyPEM, err := crypto.EncodePublicKeyPEM(signer.Public())
+	if err != nil {
+		t.Fatalf("EncodePublicKeyPEM: %v", err)
+	}
+	verifier, err := crypto.ParseVerifier(publicKeyPEM)
+	if err != nil {
+		t.Fatalf("ParseVerifier: %v", err)
+	}
+
+	data := []byte(`{"id":"lic-1","device_id":"device123"}`)
+	signature, err := signer.Sign(data)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !verifier.Verify(data, signature) {
+		t.Fatal("Verify rejected the HSM signature")
+	}
+	if verifier.Verify([]byte(`{"id":"lic-2","device_id":"device123"}`), signature) {
+		t.Fatal("Verify accepted the signature for modified data")
+	}
+
+	// 密钥不存在时打开失败
+	missing := config
+	missing.KeyLabel += "-missing"
+	if _, err := Open(missing); err == nil {
+		t.Fatal("Open with missing key succeeded, want error")
+	}
+}
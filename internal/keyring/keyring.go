@@ -0,0 +1,511 @@
+// Package keyring 提供签名密钥环功能
+// 签名密钥保存在数据库（KeyRecord）中：同一时间只有一个激活密钥用于签发许可证，
+// 轮换后的旧密钥标记为退役，其公钥仍用于验证已签发的许可证
+//
+// 私钥和AES密钥使用主密钥（AES-256-GCM）加密保存，主密钥由操作员口令通过 Argon2id 派生，
+// 只在进程内存中存在；数据库中仅保存派生参数和校验值
+package keyring
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/hsm"
+)
+
+var (
+	// ErrNoActiveKey 表示密钥环中没有激活的签名密钥
+	ErrNoActiveKey = errors.New("no active signing key (run 'licensemanager init' first)")
+
+	// ErrNoAESKey 表示密钥环中没有AES密钥
+	ErrNoAESKey = errors.New("no AES key (run 'licensemanager init' first)")
+
+	// ErrLocked 表示密钥环尚未解锁
+	ErrLocked = errors.New("keyring is locked")
+
+	// ErrWrongPassphrase 表示口令错误
+	ErrWrongPassphrase = errors.New("incorrect keyring passphrase")
+
+	// ErrNotInitialized 表示密钥环尚未设置口令
+	ErrNotInitialized = errors.New("keyring is not initialized (run 'licensemanager init' or 'licensemanager key init' first)")
+
+	// ErrInitialized 表示密钥环已经设置口令
+	ErrInitialized = errors.New("keyring is already initialized")
+)
+
+// masterCheck 主密钥校验明文，解锁时用于确认口令正确
+var masterCheck = []byte("LicenseManager keyring v1")
+
+// masterParams 主密钥记录的内容
+type masterParams struct {
+	KDF   *crypto.KDFParams `json:"kdf"`   // 口令派生参数
+	Check []byte            `json:"check"` // 用主密钥加密的校验值
+}
+
+// Keyring 签名密钥环
+type Keyring struct {
+	db        *database.DB // 数据库连接
+	masterKey []byte       // 主密钥（解锁后有效）
+}
+
+// New 创建密钥环
+// 参数：
+//   - db: 数据库连接
+//
+// 返回值：
+//   - *Keyring: 密钥环实例
+func New(db *database.DB) *Keyring {
+	return &Keyring{db: db}
+}
+
+// Initialized 返回密钥环是否已设置口令
+// 返回值：
+//   - bool: 是否已设置口令
+//   - error: 查询过程中的错误
+func (k *Keyring) Initialized() (bool, error) {
+	_, err := k.db.GetActiveKey(database.KeyTypeMaster)
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Unlock 使用口令解锁密钥环
+// 密钥环必须已经通过 Init 设置口令，解锁后会将旧版本保存的明文密钥加密
+// 参数：
+//   - passphrase: 操作员口令
+//
+// 返回值：
+//   - error: 未设置口令时返回 ErrNotInitialized，口令错误时返回 ErrWrongPassphrase
+func (k *Keyring) Unlock(passphrase []byte) error {
+	record, err := k.db.GetActiveKey(database.KeyTypeMaster)
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return ErrNotInitialized
+	}
+	if err != nil {
+		return err
+	}
+
+	var params masterParams
+	if err := json.Unmarshal(record.KeyData, &params); err != nil || params.KDF == nil {
+		return fmt.Errorf("invalid keyring master record")
+	}
+
+	masterKey, err := crypto.DeriveKey(passphrase, params.KDF)
+	if err != nil {
+		return err
+	}
+
+	check, err := crypto.DecryptAES(params.Check, masterKey)
+	if err != nil || !bytes.Equal(check, masterCheck) {
+		return ErrWrongPassphrase
+	}
+
+	k.masterKey = masterKey
+	return k.encryptPlaintextKeys()
+}
+
+// Init 使用口令创建主密钥记录并解锁密钥环
+// 数据库中已有旧版本以明文保存的密钥时，创建后将其加密
+// 参数：
+//   - passphrase: 操作员口令
+//
+// 返回值：
+//   - error: 已经设置口令时返回 ErrInitialized
+func (k *Keyring) Init(passphrase []byte) error {
+	initialized, err := k.Initialized()
+	if err != nil {
+		return err
+	}
+	if initialized {
+		return ErrInitialized
+	}
+	if len(passphrase) == 0 {
+		return errors.New("keyring passphrase must not be empty")
+	}
+
+	kdf, err := crypto.NewKDFParams()
+	if err != nil {
+		return err
+	}
+
+	masterKey, err := crypto.DeriveKey(passphrase, kdf)
+	if err != nil {
+		return err
+	}
+
+	check, err := crypto.EncryptAES(masterCheck, masterKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&masterParams{KDF: kdf, Check: check})
+	if err != nil {
+		return err
+	}
+
+	record := &database.KeyRecord{
+		KeyType: database.KeyTypeMaster,
+		KeyData: data,
+	}
+	if err := k.db.SaveKey(record); err != nil {
+		return fmt.Errorf("failed to save keyring master record: %w", err)
+	}
+
+	k.masterKey = masterKey
+	return k.encryptPlaintextKeys()
+}
+
+// encryptPlaintextKeys 加密旧版本以明文保存的密钥
+func (k *Keyring) encryptPlaintextKeys() error {
+	for _, keyType := range []string{database.KeyTypeSigning, database.KeyTypeAES} {
+		records, err := k.db.ListKeys(keyType)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.Encrypted || record.Status == database.KeyStatusExternal {
+				continue
+			}
+			if err := k.seal(record); err != nil {
+				return err
+			}
+			if err := k.db.UpdateKeyData(record); err != nil {
+				return fmt.Errorf("failed to encrypt key %d: %w", record.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// seal 使用主密钥加密记录中的密钥数据
+func (k *Keyring) seal(record *database.KeyRecord) error {
+	if k.masterKey == nil {
+		return ErrLocked
+	}
+
+	ciphertext, err := crypto.EncryptAES(record.KeyData, k.masterKey)
+	if err != nil {
+		return err
+	}
+
+	record.KeyData = ciphertext
+	record.Encrypted = true
+	return nil
+}
+
+// open 使用主密钥解密记录中的密钥数据
+func (k *Keyring) open(record *database.KeyRecord) ([]byte, error) {
+	if k.masterKey == nil {
+		return nil, ErrLocked
+	}
+	if !record.Encrypted {
+		return record.KeyData, nil
+	}
+
+	plaintext, err := crypto.DecryptAES(record.KeyData, k.masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key %d: %w", record.ID, err)
+	}
+	return plaintext, nil
+}
+
+// Signer 返回当前激活的签名密钥（需要先解锁）
+// 返回值：
+//   - crypto.Signer: 签名器
+//   - *database.KeyRecord: 密钥记录
+//   - error: 没有激活密钥时返回 ErrNoActiveKey，未解锁时返回 ErrLocked
+func (k *Keyring) Signer() (crypto.Signer, *database.KeyRecord, error) {
+	if k.masterKey == nil {
+		return nil, nil, ErrLocked
+	}
+
+	record, err := k.db.GetActiveKey(database.KeyTypeSigning)
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return nil, nil, ErrNoActiveKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKeyPEM, err := k.open(record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := crypto.ParseSigner(privateKeyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode signing key %s: %w", record.KeyID, err)
+	}
+
+	return signer, record, nil
+}
+
+// LoadSigner 返回当前激活的签名密钥，密钥环为空时从私钥文件导入
+// 用于 key init 导入密钥环引入之前通过 init 生成的 private_key.pem，导入后删除该明文文件
+// 参数：
+//   - privateKeyPaths: 候选私钥文件路径（按顺序尝试）
+//
+// 返回值：
+//   - crypto.Signer: 签名器
+//   - error: 加载过程中的错误（导入成功但删除文件失败时也返回错误，需要手动删除）
+func (k *Keyring) LoadSigner(privateKeyPaths ...string) (crypto.Signer, error) {
+	signer, _, err := k.Signer()
+	if !errors.Is(err, ErrNoActiveKey) {
+		return signer, err
+	}
+
+	for _, path := range privateKeyPaths {
+		privateKeyPEM, readErr := os.ReadFile(path)
+		if readErr != nil {
+			continue
+		}
+
+		if _, err := k.Import(privateKeyPEM); err != nil {
+			return nil, err
+		}
+		if err := removeImported(path); err != nil {
+			return nil, err
+		}
+		signer, _, err = k.Signer()
+		return signer, err
+	}
+
+	return nil, ErrNoActiveKey
+}
+
+// Import 导入已有私钥作为激活密钥，当前激活密钥（如有）标记为退役
+// 参数：
+//   - privateKeyPEM: PEM编码的私钥（RSA或Ed25519）
+//
+// 返回值：
+//   - *database.KeyRecord: 新的密钥记录
+//   - error: 导入过程中的错误
+func (k *Keyring) Import(privateKeyPEM []byte) (*database.KeyRecord, error) {
+	record, err := newRecord(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.seal(record); err != nil {
+		return nil, err
+	}
+
+	if err := k.db.RotateKey(record); err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return record, nil
+}
+
+// Rotate 生成新的签名密钥并将当前激活密钥标记为退役
+// 退役密钥不再用于签发，但其公钥仍包含在 PublicKeys 中
+// 参数：
+//   - algorithm: 新密钥的签名算法
+//
+// 返回值：
+//   - *database.KeyRecord: 新的密钥记录
+//   - error: 轮换过程中的错误
+func (k *Keyring) Rotate(algorithm crypto.Algorithm) (*database.KeyRecord, error) {
+	privateKeyPEM, _, err := crypto.GenerateKeyPair(algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key pair: %w", algorithm, err)
+	}
+
+	return k.Import(privateKeyPEM)
+}
+
+// RegisterExternal 登记外部签名设备（HSM）中的签名密钥
+// 密钥环只保存公钥和设备中的密钥标识，使其包含在 PublicKeys 中分发给客户端；已登记的密钥不重复登记
+// 参数：
+//   - signer: 外部签名器
+//   - ref: 设备中的密钥标识（例如 PKCS#11 URI，不含PIN）
+//
+// 返回值：
+//   - *database.KeyRecord: 密钥记录
+//   - error: 登记过程中的错误
+func (k *Keyring) RegisterExternal(signer crypto.Signer, ref string) (*database.KeyRecord, error) {
+	keyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := k.db.GetKeyByKeyID(database.KeyTypeSigning, keyID)
+	if err == nil {
+		return record, nil
+	}
+	if !errors.Is(err, database.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	publicKeyPEM, err := crypto.EncodePublicKeyPEM(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	record = &database.KeyRecord{
+		KeyID:     keyID,
+		KeyType:   database.KeyTypeSigning,
+		Algorithm: string(signer.Algorithm()),
+		Status:    database.KeyStatusExternal,
+		KeyData:   []byte(ref),
+		PublicKey: string(publicKeyPEM),
+	}
+	if err := k.db.SaveKey(record); err != nil {
+		return nil, fmt.Errorf("failed to register external signing key: %w", err)
+	}
+	return record, nil
+}
+
+// OpenHSM 打开 PKCS#11 设备中的签名密钥，并将其公钥登记到密钥环（见 RegisterExternal）
+// 登记只写入公钥，不需要解锁密钥环
+// 参数：
+//   - config: PKCS#11 设备配置
+//
+// 返回值：
+//   - *hsm.Signer: 签名器（使用完毕后调用 Close）
+//   - error: 打开或登记过程中的错误
+func (k *Keyring) OpenHSM(config hsm.Config) (*hsm.Signer, error) {
+	signer, err := hsm.Open(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := k.RegisterExternal(signer, config.String()); err != nil {
+		signer.Close()
+		return nil, err
+	}
+	return signer, nil
+}
+
+// AESKey 返回许可证加密使用的AES密钥（需要先解锁）
+// 返回值：
+//   - []byte: AES密钥（32字节）
+//   - error: 没有AES密钥时返回 ErrNoAESKey，未解锁时返回 ErrLocked
+func (k *Keyring) AESKey() ([]byte, error) {
+	if k.masterKey == nil {
+		return nil, ErrLocked
+	}
+
+	record, err := k.db.GetActiveKey(database.KeyTypeAES)
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return nil, ErrNoAESKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return k.open(record)
+}
+
+// LoadAESKey 返回AES密钥，密钥环中没有AES密钥时从密钥文件导入
+// 用于 key init 导入旧版本 init 生成的 aes_key.bin，导入后删除该明文文件（客户端使用的密钥通过 key export-aes 导出）
+// 参数：
+//   - aesKeyPaths: 候选AES密钥文件路径（按顺序尝试）
+//
+// 返回值：
+//   - []byte: AES密钥（32字节）
+//   - error: 加载过程中的错误（导入成功但删除文件失败时也返回错误，需要手动删除）
+func (k *Keyring) LoadAESKey(aesKeyPaths ...string) ([]byte, error) {
+	aesKey, err := k.AESKey()
+	if !errors.Is(err, ErrNoAESKey) {
+		return aesKey, err
+	}
+
+	for _, path := range aesKeyPaths {
+		aesKey, readErr := os.ReadFile(path)
+		if readErr != nil {
+			continue
+		}
+
+		if err := k.ImportAESKey(aesKey); err != nil {
+			return nil, err
+		}
+		if err := removeImported(path); err != nil {
+			return nil, err
+		}
+		return aesKey, nil
+	}
+
+	return nil, ErrNoAESKey
+}
+
+// removeImported 删除已导入密钥环的明文密钥文件
+func removeImported(path string) error {
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("key imported into the keyring, but failed to remove plaintext file %s (delete it manually): %w", path, err)
+	}
+	return nil
+}
+
+// ImportAESKey 导入AES密钥，替换已有的AES密钥
+// 参数：
+//   - aesKey: AES密钥（32字节）
+//
+// 返回值：
+//   - error: 导入过程中的错误
+func (k *Keyring) ImportAESKey(aesKey []byte) error {
+	if len(aesKey) != 32 {
+		return fmt.Errorf("AES key must be 32 bytes, got %d bytes", len(aesKey))
+	}
+
+	record := &database.KeyRecord{
+		KeyType: database.KeyTypeAES,
+		KeyData: append([]byte{}, aesKey...),
+	}
+	if err := k.seal(record); err != nil {
+		return err
+	}
+
+	if err := k.db.RotateKey(record); err != nil {
+		return fmt.Errorf("failed to save AES key: %w", err)
+	}
+	return nil
+}
+
+// PublicKeys 返回所有可用于验证的公钥（激活和已退役）
+// 返回值：
+//   - []byte: PEM编码的公钥集合（多个 PUBLIC KEY 块），可直接分发给客户端
+//   - error: 查询过程中的错误
+func (k *Keyring) PublicKeys() ([]byte, error) {
+	records, err := k.db.ListKeys(database.KeyTypeSigning)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle []byte
+	for _, record := range records {
+		bundle = append(bundle, record.PublicKey...)
+	}
+	return bundle, nil
+}
+
+// newRecord 根据私钥创建签名密钥记录
+func newRecord(privateKeyPEM []byte) (*database.KeyRecord, error) {
+	signer, err := crypto.ParseSigner(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	keyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeyPEM, err := crypto.EncodePublicKeyPEM(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &database.KeyRecord{
+		KeyID:     keyID,
+		KeyType:   database.KeyTypeSigning,
+		Algorithm: string(signer.Algorithm()),
+		KeyData:   privateKeyPEM,
+		PublicKey: string(publicKeyPEM),
+	}, nil
+}
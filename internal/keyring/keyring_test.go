@@ -0,0 +1,124 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+)
+
+// newTestDB 创建临时数据库
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "license.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestInitUnlock(t *testing.T) {
+	db := newTestDB(t)
+	passphrase := []byte("correct horse")
+
+	// 未初始化的密钥环不会隐式设置口令
+	if err := New(db).Unlock(passphrase); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Unlock before Init: err = %v, want ErrNotInitialized", err)
+	}
+	if _, err := Open(db, ""); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Open before Init: err = %v, want ErrNotInitialized", err)
+	}
+	if initialized, err := New(db).Initialized(); err != nil || initialized {
+		t.Fatalf("Initialized = %v, %v; want false, nil", initialized, err)
+	}
+
+	ring := New(db)
+	if err := ring.Init(nil); err == nil {
+		t.Fatal("Init with empty passphrase succeeded, want error")
+	}
+	if err := ring.Init(passphrase); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := New(db).Init(passphrase); !errors.Is(err, ErrInitialized) {
+		t.Fatalf("second Init: err = %v, want ErrInitialized", err)
+	}
+
+	aesKey := bytes.Repeat([]byte{0x01}, 32)
+	if err := ring.ImportAESKey(aesKey); err != nil {
+		t.Fatalf("ImportAESKey: %v", err)
+	}
+
+	// AES密钥加密保存在数据库中
+	record, err := db.GetActiveKey(database.KeyTypeAES)
+	if err != nil {
+		t.Fatalf("GetActiveKey: %v", err)
+	}
+	if !record.Encrypted || bytes.Contains(record.KeyData, aesKey) {
+		t.Fatalf("AES key record is not sealed: encrypted = %v", record.Encrypted)
+	}
+
+	locked := New(db)
+	if _, err := locked.AESKey(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("AESKey before Unlock: err = %v, want ErrLocked", err)
+	}
+	if err := locked.Unlock([]byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("Unlock with wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+	if err := locked.Unlock(passphrase); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	got, err := locked.AESKey()
+	if err != nil {
+		t.Fatalf("AESKey: %v", err)
+	}
+	if !bytes.Equal(got, aesKey) {
+		t.Fatalf("AESKey = %x, want %x", got, aesKey)
+	}
+}
+
+func TestLoadRemovesImportedFiles(t *testing.T) {
+	ring := New(newTestDB(t))
+	if err := ring.Init([]byte("correct horse")); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	dir := t.TempDir()
+	privateKeyPEM, _, err := crypto.GenerateKeyPair(crypto.AlgorithmEd25519)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	privatePath := filepath.Join(dir, "private_key.pem")
+	aesPath := filepath.Join(dir, "aes_key.bin")
+	if err := os.WriteFile(privatePath, privateKeyPEM, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(aesPath, bytes.Repeat([]byte{0x01}, 32), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// 导入密钥环后删除明文密钥文件
+	if _, err := ring.LoadSigner(privatePath); err != nil {
+		t.Fatalf("LoadSigner: %v", err)
+	}
+	if _, err := ring.LoadAESKey(aesPath); err != nil {
+		t.Fatalf("LoadAESKey: %v", err)
+	}
+	for _, path := range []string{privatePath, aesPath} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("Stat %s after import: err = %v, want ErrNotExist", path, err)
+		}
+	}
+
+	// 之后从密钥环加载
+	if _, err := ring.LoadSigner(privatePath); err != nil {
+		t.Fatalf("LoadSigner from keyring: %v", err)
+	}
+	if _, err := ring.LoadAESKey(aesPath); err != nil {
+		t.Fatalf("LoadAESKey from keyring: %v", err)
+	}
+}
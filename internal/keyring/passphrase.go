@@ -0,0 +1,135 @@
+// Package keyring 提供签名密钥环功能
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv 提供密钥环口令的环境变量
+const PassphraseEnv = "LICENSEMANAGER_PASSPHRASE"
+
+// ErrNoPassphrase 表示无法获取口令（未指定口令文件、未设置环境变量且标准输入不是终端）
+var ErrNoPassphrase = errors.New("keyring passphrase required (use --passphrase-file or " + PassphraseEnv + ")")
+
+// Open 创建密钥环并使用口令解锁
+// 密钥环必须已经初始化（见 Create），不会隐式设置口令
+// 参数：
+//   - db: 数据库连接
+//   - passphraseFile: 口令文件路径（为空时使用环境变量或终端输入，见 ReadPassphrase）
+//
+// 返回值：
+//   - *Keyring: 已解锁的密钥环
+//   - error: 未初始化时返回 ErrNotInitialized，获取口令或解锁失败时的错误
+func Open(db *database.DB, passphraseFile string) (*Keyring, error) {
+	ring := New(db)
+
+	initialized, err := ring.Initialized()
+	if err != nil {
+		return nil, err
+	}
+	if !initialized {
+		return nil, ErrNotInitialized
+	}
+
+	passphrase, err := ReadPassphrase(passphraseFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ring.Unlock(passphrase); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// Create 创建密钥环并设置口令（从终端输入时要求再次输入确认）
+// 参数：
+//   - db: 数据库连接
+//   - passphraseFile: 口令文件路径（为空时使用环境变量或终端输入，见 ReadPassphrase）
+//
+// 返回值：
+//   - *Keyring: 已解锁的密钥环
+//   - error: 已经初始化时返回 ErrInitialized，获取口令或初始化失败时的错误
+func Create(db *database.DB, passphraseFile string) (*Keyring, error) {
+	ring := New(db)
+
+	initialized, err := ring.Initialized()
+	if err != nil {
+		return nil, err
+	}
+	if initialized {
+		return nil, ErrInitialized
+	}
+
+	passphrase, err := ReadPassphrase(passphraseFile, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ring.Init(passphrase); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// ReadPassphrase 读取密钥环口令
+// 依次尝试口令文件、环境变量 LICENSEMANAGER_PASSPHRASE 和终端输入
+// 参数：
+//   - path: 口令文件路径（为空时跳过，文件末尾的换行会被去除）
+//   - confirm: 从终端输入时是否要求再次输入确认（首次设置口令时使用）
+//
+// 返回值：
+//   - []byte: 口令
+//   - error: 无法获取口令时返回 ErrNoPassphrase
+func ReadPassphrase(path string, confirm bool) ([]byte, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, ErrNoPassphrase
+	}
+
+	passphrase, err := promptPassphrase(fd, "Keyring passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	if confirm {
+		again, err := promptPassphrase(fd, "Confirm passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// promptPassphrase 在终端提示并读取口令（不回显）
+func promptPassphrase(fd int, prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,86 @@
+// Package license 提供许可证吊销列表生成功能
+package license
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// GenerateCRL 生成并签名许可证吊销列表
+// 参数：
+//   - entries: 吊销条目
+//   - validity: 有效期（NextUpdate = 签发时间 + validity）
+//   - signer: 签发许可证使用的签名器
+//
+// 返回值：
+//   - []byte: CRL文件内容（SignedCRL 的JSON）
+//   - error: 生成过程中的错误
+func GenerateCRL(entries []license.CRLEntry, validity time.Duration, signer crypto.Signer) ([]byte, error) {
+	now := time.Now().UTC()
+	if entries == nil {
+		entries = []license.CRLEntry{}
+	}
+
+	crl := &license.CRL{
+		IssuedAt:   now,
+		NextUpdate: now.Add(validity),
+		Entries:    entries,
+	}
+
+	payload, err := json.Marshal(crl)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	// 签名覆盖带域分隔前缀的数据（见 SignedCRL.SignedData）
+	signed := &license.SignedCRL{
+		KeyID:   keyID,
+		Payload: payload,
+	}
+	signed.Signature, err = signer.Sign(signed.SignedData())
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(signed, "", "  ")
+}
+
+// CRLEntriesFromRecords 将已撤销的许可证记录转换为吊销条目
+// 参数：
+//   - records: 已撤销的许可证记录
+//
+// 返回值：
+//   - []license.CRLEntry: 吊销条目（无法计算序列号的记录会被跳过）
+func CRLEntriesFromRecords(records []*database.LicenseRecord) []license.CRLEntry {
+	entries := make([]license.CRLEntry, 0, len(records))
+	for _, record := range records {
+		if !record.IsRevoked() {
+			continue
+		}
+
+		// 新版许可证使用许可证ID作为序列号，旧版许可证使用指纹
+		serial := record.LicenseID
+		if serial == "" {
+			var err error
+			if serial, err = license.LicenseSerial(record.LicenseKey); err != nil {
+				continue
+			}
+		}
+
+		entries = append(entries, license.CRLEntry{
+			Serial:    serial,
+			RevokedAt: *record.RevokedAt,
+			Reason:    record.RevokeReason,
+		})
+	}
+	return entries
+}
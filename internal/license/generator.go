@@ -2,32 +2,79 @@
 package license
 
 import (
-	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 	
+	"github.com/google/uuid"
+	
 	"github.com/Zeroshcat/LicenseManager/internal/crypto"
 	"github.com/Zeroshcat/LicenseManager/pkg/license"
 )
 
+// DeviceKeyLookup 查询设备注册时提交的X25519公钥，设备没有公钥时返回 nil
+type DeviceKeyLookup func(deviceID string) ([]byte, error)
+
 // Generator 许可证生成器
 type Generator struct {
-	privateKey *rsa.PrivateKey // RSA私钥（用于签名）
-	aesKey     []byte          // AES密钥（用于加密）
+	signer     crypto.Signer   // 签名器（RSA或Ed25519）
+	aesKey     []byte          // 主AES密钥
+	productID  string          // 产品ID（为空时直接使用主AES密钥加密）
+	signOnly   bool            // 仅签名，不加密载荷
+	deviceKeys DeviceKeyLookup // 设备公钥查询（可选）
+	issuer     string          // 签发者（写入许可证）
 }
 
 // NewGenerator 创建许可证生成器
 // 参数：
-//   - privateKey: RSA私钥
+//   - signer: 签名器（见 crypto.ParseSigner）
 //   - aesKey: AES密钥（32字节）
 // 返回值：
 //   - *Generator: 许可证生成器实例
-func NewGenerator(privateKey *rsa.PrivateKey, aesKey []byte) *Generator {
+func NewGenerator(signer crypto.Signer, aesKey []byte) *Generator {
 	return &Generator{
-		privateKey: privateKey,
-		aesKey:     aesKey,
+		signer: signer,
+		aesKey: aesKey,
+		issuer: license.DefaultIssuer,
+	}
+}
+
+// SetIssuer 设置写入许可证的签发者
+// 参数：
+//   - issuer: 签发者名称（为空时使用 license.DefaultIssuer）
+func (g *Generator) SetIssuer(issuer string) {
+	if issuer == "" {
+		issuer = license.DefaultIssuer
 	}
+	g.issuer = issuer
+}
+
+// SetSignatureOnly 设置是否只签名、不加密许可证内容
+// 仅签名的许可证可以用 license.NewPublicVerifier 只凭公钥验证，客户端可以查看许可证内容，且无需分发AES密钥
+// 参数：
+//   - signOnly: 是否只签名
+func (g *Generator) SetSignatureOnly(signOnly bool) {
+	g.signOnly = signOnly
+}
+
+// SetDeviceKeys 设置设备公钥查询
+// 设置后，设备注册时提交了公钥的许可证加密到该设备公钥（优先于产品密钥），
+// 只有持有对应私钥的设备能够解密，客户端无需内置共享的AES密钥
+// 参数：
+//   - lookup: 设备公钥查询（为 nil 时不使用设备公钥）
+func (g *Generator) SetDeviceKeys(lookup DeviceKeyLookup) {
+	g.deviceKeys = lookup
+}
+
+// SetProduct 设置许可证所属的产品（或客户）
+// 设置后许可证使用从主AES密钥派生的产品密钥加密，并在许可证头部和载荷中记录产品ID，
+// 只有持有该产品密钥的客户端能够解密；仅签名的许可证通过 IssueOptions.ProductID 指定产品
+// 参数：
+//   - productID: 产品或客户ID（为空时使用主AES密钥）
+func (g *Generator) SetProduct(productID string) {
+	g.productID = productID
 }
 
 // Generate 生成许可证
@@ -40,39 +87,240 @@ func NewGenerator(privateKey *rsa.PrivateKey, aesKey []byte) *Generator {
 //   - string: base64编码的许可证密钥
 //   - error: 生成过程中的错误
 func (g *Generator) Generate(deviceID string, licenseType license.LicenseType, expiryDate time.Time, features []string) (string, error) {
-	// 创建许可证对象
-	lic := &license.License{
+	_, licenseKey, err := g.Issue(deviceID, licenseType, expiryDate, features)
+	return licenseKey, err
+}
+
+// IssueOptions 签发许可证的参数
+type IssueOptions struct {
+	DeviceID         string               // 设备ID
+	ProductID        string               // 产品ID（为空时使用 SetProduct 设置的产品）
+	VersionRange     string               // 适用的产品版本范围（例如 2.x，为空表示不限版本）
+	LicenseType      license.LicenseType  // 许可证类型
+	Seats            int                  // 并发席位数（浮动许可证必须大于0）
+	MaxActivations   int                  // 最大激活设备数（大于0时为多设备许可证，DeviceID 为客户ID，仅网络验证和双重验证）
+	TrialDays        int                  // 试用天数（试用许可证必须大于0，DeviceID 可为空）
+	ExpiryDate       time.Time            // 到期时间（永久许可证为零值）
+	NotBefore        time.Time            // 生效时间（零值表示签发后立即生效）
+	Perpetual        bool                 // 永久许可证（不过期）
+	MaintenanceUntil time.Time            // 维护截止日期（仅永久许可证，可为零值）
+	Features         []string             // 功能列表
+	Entitlements     license.Entitlements // 授权项（功能开关、数量限制等，可为 nil）
+	RequestNonce     string               // 离线续期请求的随机数（响应续期请求时设置，见 IssueRenewal）
+	DevicePublicKey  []byte               // 设备X25519公钥（为空时使用 SetDeviceKeys 查询）
+}
+
+// Issue 签发许可证，并返回许可证对象（包含生成的许可证ID）
+// 参数：
+//   - deviceID: 设备ID
+//   - licenseType: 许可证类型
+//   - expiryDate: 到期时间
+//   - features: 功能列表
+// 返回值：
+//   - *license.License: 签发的许可证
+//   - string: base64编码的许可证密钥
+//   - error: 生成过程中的错误
+func (g *Generator) Issue(deviceID string, licenseType license.LicenseType, expiryDate time.Time, features []string) (*license.License, string, error) {
+	return g.IssueWith(IssueOptions{
 		DeviceID:    deviceID,
-		ExpiryDate:  expiryDate,
 		LicenseType: licenseType,
+		ExpiryDate:  expiryDate,
 		Features:    features,
-		CreatedAt:   time.Now(),
+	})
+}
+
+// IssueWith 按参数签发许可证，并返回许可证对象（包含生成的许可证ID）
+// 参数：
+//   - opts: 签发参数
+// 返回值：
+//   - *license.License: 签发的许可证
+//   - string: base64编码的许可证密钥
+//   - error: 生成过程中的错误
+func (g *Generator) IssueWith(opts IssueOptions) (*license.License, string, error) {
+	productID := opts.ProductID
+	if productID == "" {
+		productID = g.productID
+	}
+	if g.productID != "" && productID != g.productID {
+		return nil, "", fmt.Errorf("product %q does not match the product key %q", productID, g.productID)
+	}
+	if opts.VersionRange != "" {
+		if productID == "" {
+			return nil, "", errors.New("version range requires a product ID")
+		}
+		if _, err := license.ParseVersionRange(opts.VersionRange); err != nil {
+			return nil, "", err
+		}
+	}
+	if opts.LicenseType == license.LicenseTypeFloating && opts.Seats <= 0 {
+		return nil, "", errors.New("floating license requires a positive number of seats")
+	}
+	if opts.LicenseType != license.LicenseTypeFloating && opts.Seats != 0 {
+		return nil, "", errors.New("seats are only supported for floating licenses")
+	}
+	if opts.MaxActivations < 0 {
+		return nil, "", errors.New("max activations must not be negative")
+	}
+	// 激活数只由授权服务器限制，离线验证不检查多设备许可证的设备ID，因此只允许网络验证和双重验证的许可证
+	if opts.MaxActivations > 0 && opts.LicenseType != license.LicenseTypeOnline && opts.LicenseType != license.LicenseTypeDual {
+		return nil, "", errors.New("max activations are only supported for online and dual licenses")
+	}
+	if opts.LicenseType == license.LicenseTypeTrial && opts.TrialDays <= 0 {
+		return nil, "", errors.New("trial license requires a positive number of trial days")
+	}
+	if opts.LicenseType != license.LicenseTypeTrial && opts.TrialDays != 0 {
+		return nil, "", errors.New("trial days are only supported for trial licenses")
+	}
+	if g.signOnly && opts.DevicePublicKey != nil {
+		return nil, "", errors.New("signature-only licenses cannot be encrypted to a device key")
+	}
+	if err := checkValidity(opts); err != nil {
+		return nil, "", err
+	}
+	
+	// 创建许可证对象
+	lic := &license.License{
+		ID:               uuid.NewString(),
+		Issuer:           g.issuer,
+		Version:          license.FormatVersion,
+		DeviceID:         opts.DeviceID,
+		ProductID:        productID,
+		VersionRange:     opts.VersionRange,
+		ExpiryDate:       opts.ExpiryDate,
+		NotBefore:        opts.NotBefore,
+		Perpetual:        opts.Perpetual,
+		MaintenanceUntil: opts.MaintenanceUntil,
+		LicenseType:      opts.LicenseType,
+		Seats:            opts.Seats,
+		MaxActivations:   opts.MaxActivations,
+		TrialDays:        opts.TrialDays,
+		Features:         opts.Features,
+		Entitlements:     opts.Entitlements,
+		RequestNonce:     opts.RequestNonce,
+		CreatedAt:        time.Now(),
 	}
 	
 	// 序列化为JSON
 	jsonData, err := json.Marshal(lic)
 	if err != nil {
-		return "", err
+		return nil, "", err
+	}
+	
+	// 查询设备公钥（仅签名模式不加密；多设备许可证由多台设备共用、未绑定设备的试用许可证没有设备，不加密到单台设备的公钥）
+	devicePublicKey := opts.DevicePublicKey
+	if devicePublicKey == nil && g.deviceKeys != nil && !g.signOnly && !lic.IsMultiDevice() && lic.DeviceID != "" {
+		devicePublicKey, err = g.deviceKeys(opts.DeviceID)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	
+	// 封装为许可证容器，对头部和密文签名
+	signatureAlgorithm, err := envelopeAlgorithm(g.signer.Algorithm())
+	if err != nil {
+		return nil, "", err
+	}
+	
+	// 写入签名密钥ID，验证器据此在轮换后的多个公钥中选择
+	keyID, err := crypto.KeyID(g.signer.Public())
+	if err != nil {
+		return nil, "", err
+	}
+	
+	envelope := license.NewEnvelope(keyID, signatureAlgorithm, nil)
+	switch {
+	case g.signOnly:
+		envelope.EncryptionAlgorithm = license.EncryptionNone
+	case devicePublicKey != nil:
+		envelope.EncryptionAlgorithm = license.EncryptionX25519AES256GCM
+		envelope.EncryptionKeyID = crypto.X25519KeyID(devicePublicKey)
+	default:
+		envelope.EncryptionKeyID = g.productID
+	}
+	
+	// 头部确定后加密载荷，头部作为附加数据参与认证，无法与其他许可证的头部互换
+	aad, err := envelope.AssociatedData()
+	if err != nil {
+		return nil, "", err
+	}
+	
+	// 按头部中的加密算法加密到设备公钥，或使用AES加密（指定产品时使用派生的产品密钥），仅签名模式保留明文
+	if envelope.EncryptionAlgorithm == license.EncryptionX25519AES256GCM {
+		envelope.Payload, err = crypto.EncryptX25519(jsonData, devicePublicKey, aad)
+	} else {
+		envelope.Payload, err = g.encrypt(jsonData, aad)
+	}
+	if err != nil {
+		return nil, "", err
 	}
 	
-	// 使用AES加密
-	encryptedData, err := crypto.EncryptAES(jsonData, g.aesKey)
+	signedData, err := envelope.SignedData()
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 	
-	// 使用RSA签名
-	signature, err := crypto.SignData(encryptedData, g.privateKey)
+	envelope.Signature, err = g.signer.Sign(signedData)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 	
-	// 组合数据：签名 + 密文
-	licenseData := append(signature, encryptedData...)
+	licenseData, err := envelope.Marshal()
+	if err != nil {
+		return nil, "", err
+	}
 	
 	// Base64编码
 	licenseKey := base64.StdEncoding.EncodeToString(licenseData)
 	
-	return licenseKey, nil
+	return lic, licenseKey, nil
+}
+
+// envelopeAlgorithm 返回签名算法对应的许可证容器算法标识
+func envelopeAlgorithm(algorithm crypto.Algorithm) (license.SignatureAlgorithm, error) {
+	switch algorithm {
+	case crypto.AlgorithmRSA:
+		return license.SignatureRSAPSSSHA256, nil
+	case crypto.AlgorithmEd25519:
+		return license.SignatureEd25519, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", license.ErrUnsupportedAlgorithm, algorithm)
+	}
+}
+
+// checkValidity 检查有效期相关参数（到期时间、生效时间、永久许可证和维护截止日期）
+func checkValidity(opts IssueOptions) error {
+	switch {
+	case opts.Perpetual && !opts.ExpiryDate.IsZero():
+		return errors.New("perpetual licenses have no expiry date")
+	case opts.Perpetual && opts.LicenseType == license.LicenseTypeTrial:
+		return errors.New("trial licenses cannot be perpetual")
+	case !opts.Perpetual && opts.ExpiryDate.IsZero():
+		return errors.New("expiry date is required unless the license is perpetual")
+	case !opts.Perpetual && !opts.MaintenanceUntil.IsZero():
+		return errors.New("maintenance date is only supported for perpetual licenses")
+	case !opts.Perpetual && !opts.NotBefore.IsZero() && !opts.NotBefore.Before(opts.ExpiryDate):
+		return errors.New("not-before date must be before the expiry date")
+	}
+	return nil
 }
 
+// encrypt 加密许可证JSON（仅签名模式直接返回明文）
+func (g *Generator) encrypt(jsonData, aad []byte) ([]byte, error) {
+	if g.signOnly {
+		if g.productID != "" {
+			return nil, errors.New("product keys require an encrypted license")
+		}
+		return jsonData, nil
+	}
+
+	aesKey := g.aesKey
+	if g.productID != "" {
+		var err error
+		aesKey, err = crypto.DeriveProductKey(g.aesKey, g.productID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return crypto.EncryptAESWithAAD(jsonData, aesKey, aad)
+}
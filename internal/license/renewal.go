@@ -0,0 +1,145 @@
+// Package license 提供离线续期请求处理功能
+package license
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// ErrDeviceKeyMismatch 表示离线续期请求中的设备公钥与设备已登记的公钥不一致（或设备尚未登记公钥）
+var ErrDeviceKeyMismatch = errors.New("device public key does not match the registered key")
+
+// IssueRenewal 处理离线续期请求，签发绑定到该请求的响应许可证并保存记录
+// 响应许可证沿用原许可证的产品、功能和授权项，绑定到请求的设备，加密到请求中的设备公钥，
+// 并写入请求的随机数（客户端通过 OfflineVerifier.InstallRenewal 安装）；多设备许可证要求设备已激活，响应许可证仍为多设备许可证，
+// 请求的设备在新许可证上保持激活。请求的MAC使用原许可证对应的AES密钥验证（见 license.RenewalRequest.VerifyMAC），
+// 请求中的设备公钥必须与设备已登记的公钥一致（见 licensemanager device bind --public-key），续期不会登记新的设备公钥
+// 参数：
+//   - db: 数据库连接
+//   - g: 许可证生成器
+//   - req: 续期请求（见 license.ParseRenewalRequest）
+//   - expiryDate: 新的到期时间（零值表示沿用原许可证的到期时间，永久许可证必须为零值）
+//
+// 返回值：
+//   - *database.LicenseRecord: 新签发的许可证记录
+//   - error: 许可证不存在、已撤销、MAC无效、设备不匹配或签发失败时的错误
+func IssueRenewal(db *database.DB, g *Generator, req *license.RenewalRequest, expiryDate time.Time) (*database.LicenseRecord, error) {
+	record, err := db.GetLicenseByLicenseID(req.LicenseID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", license.ErrLicenseNotFound, err)
+	}
+	if record.IsRevoked() {
+		return nil, fmt.Errorf("%w: %s", license.ErrRevokedLicense, record.LicenseID)
+	}
+
+	switch license.LicenseType(record.LicenseType) {
+	case license.LicenseTypeFloating:
+		return nil, errors.New("floating licenses require the license server and cannot be renewed offline")
+	case license.LicenseTypeTrial:
+		return nil, errors.New("trial licenses cannot be renewed")
+	}
+
+	// 请求使用客户端持有的AES密钥计算MAC（产品许可证的客户端只持有产品密钥）
+	requestKey := g.aesKey
+	if record.ProductID != "" {
+		if requestKey, err = crypto.DeriveProductKey(g.aesKey, record.ProductID); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.VerifyMAC(requestKey); err != nil {
+		return nil, err
+	}
+
+	// 多设备许可证的设备必须已经激活，其他许可证的设备必须与原许可证一致
+	var activation *database.ActivationRecord
+	if record.MaxActivations > 0 {
+		if activation, err = db.GetActivation(record.ID, req.DeviceID); err != nil {
+			return nil, fmt.Errorf("%w: %s", license.ErrNotActivated, req.DeviceID)
+		}
+	} else if record.DeviceID != req.DeviceID {
+		return nil, fmt.Errorf("%w: license is bound to %s", license.ErrDeviceMismatch, record.DeviceID)
+	}
+
+	// 响应许可证加密到设备已登记的公钥，请求中的公钥只用于核对
+	registeredKey, err := db.GetDevicePublicKey(req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if registeredKey == nil {
+		return nil, fmt.Errorf("%w: device %s has no registered public key", ErrDeviceKeyMismatch, req.DeviceID)
+	}
+	if !bytes.Equal(registeredKey, req.PublicKey) {
+		return nil, fmt.Errorf("%w: device %s", ErrDeviceKeyMismatch, req.DeviceID)
+	}
+
+	if expiryDate.IsZero() && !record.Perpetual {
+		expiryDate = record.ExpiryDate
+	}
+
+	features, entitlements, err := recordEntitlements(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多设备许可证的设备ID为客户ID
+	deviceID := req.DeviceID
+	if activation != nil {
+		deviceID = record.DeviceID
+	}
+
+	lic, licenseKey, err := g.IssueWith(IssueOptions{
+		DeviceID:         deviceID,
+		ProductID:        record.ProductID,
+		VersionRange:     record.VersionRange,
+		LicenseType:      license.LicenseType(record.LicenseType),
+		Seats:            record.Seats,
+		MaxActivations:   record.MaxActivations,
+		TrialDays:        record.TrialDays,
+		ExpiryDate:       expiryDate,
+		NotBefore:        record.NotBefore,
+		Perpetual:        record.Perpetual,
+		MaintenanceUntil: record.MaintenanceUntil,
+		Features:         features,
+		Entitlements:     entitlements,
+		RequestNonce:     req.Nonce,
+		DevicePublicKey:  registeredKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate license: %w", err)
+	}
+
+	renewed := &database.LicenseRecord{
+		LicenseID:        lic.ID,
+		DeviceID:         deviceID,
+		ProductID:        record.ProductID,
+		AppID:            record.AppID,
+		VersionRange:     record.VersionRange,
+		LicenseKey:       licenseKey,
+		LicenseType:      record.LicenseType,
+		Seats:            record.Seats,
+		MaxActivations:   record.MaxActivations,
+		TrialDays:        record.TrialDays,
+		Features:         record.Features,
+		Entitlements:     record.Entitlements,
+		ExpiryDate:       expiryDate,
+		NotBefore:        record.NotBefore,
+		Perpetual:        record.Perpetual,
+		MaintenanceUntil: record.MaintenanceUntil,
+	}
+	if _, err := db.SaveLicense(renewed); err != nil {
+		return nil, err
+	}
+	if activation != nil {
+		if _, _, err := db.ActivateDevice(renewed, req.DeviceID, "", activation.AppID); err != nil {
+			return nil, err
+		}
+	}
+
+	return renewed, nil
+}
@@ -2,31 +2,34 @@
 package license
 
 import (
-	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 	
 	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
 	"github.com/Zeroshcat/LicenseManager/pkg/license"
 )
 
 // Verifier 许可证验证器
 type Verifier struct {
-	publicKey *rsa.PublicKey // RSA公钥（用于验证签名）
-	aesKey    []byte         // AES密钥（用于解密）
+	keys   *license.KeySet // 受信任的签名公钥（用于验证签名）
+	aesKey []byte          // 主AES密钥（用于解密，产品许可证使用其派生密钥）
 }
 
 // NewVerifier 创建许可证验证器
 // 参数：
-//   - publicKey: RSA公钥
-//   - aesKey: AES密钥（32字节）
+//   - keys: 受信任的签名公钥集合（当前公钥 + 已退役公钥）
+//   - aesKey: 主AES密钥（32字节），可解密全部产品的许可证
 // 返回值：
 //   - *Verifier: 许可证验证器实例
-func NewVerifier(publicKey *rsa.PublicKey, aesKey []byte) *Verifier {
+func NewVerifier(keys *license.KeySet, aesKey []byte) *Verifier {
 	return &Verifier{
-		publicKey: publicKey,
-		aesKey:    aesKey,
+		keys:   keys,
+		aesKey: aesKey,
 	}
 }
 
@@ -38,31 +41,84 @@ func NewVerifier(publicKey *rsa.PublicKey, aesKey []byte) *Verifier {
 //   - *license.VerifyResult: 验证结果
 //   - error: 验证过程中的错误
 func (v *Verifier) Verify(licenseKey string, deviceID string) (*license.VerifyResult, error) {
+	lic, err := v.Decode(licenseKey)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 检查设备ID（多设备许可证和未绑定设备的试用许可证见 MatchesDevice）
+	if !lic.MatchesDevice(deviceID) {
+		return nil, license.ErrDeviceMismatch
+	}
+	
+	// 检查是否过期（永久许可证不过期）
+	now := time.Now()
+	expired := lic.IsExpired(now)
+	
+	result := &license.VerifyResult{
+		LicenseID:        lic.ID,
+		Valid:            !expired,
+		Expired:          expired,
+		ExpiryDate:       lic.ExpiryDate,
+		NotBefore:        lic.NotBefore,
+		Perpetual:        lic.Perpetual,
+		MaintenanceUntil: lic.MaintenanceUntil,
+		DeviceID:         lic.DeviceID,
+		LicenseType:      string(lic.LicenseType),
+		Features:         lic.Features,
+		Entitlements:     lic.Entitlements,
+		Message:          "License verified",
+	}
+	
+	// 检查是否已到生效时间
+	if lic.IsNotYetValid(now) {
+		result.Valid = false
+		result.Reason = license.ReasonNotYetValid
+		result.Message = "License not yet valid"
+		return result, license.ErrLicenseNotYetValid
+	}
+	
+	if expired {
+		result.Message = "License expired"
+		return result, license.ErrExpiredLicense
+	}
+	
+	return result, nil
+}
+
+// Decode 验证签名并解密许可证（不检查设备ID和到期时间）
+// 参数：
+//   - licenseKey: base64编码的许可证密钥
+// 返回值：
+//   - *license.License: 许可证对象
+//   - error: 签名无效或解密失败时返回 license.ErrInvalidLicense，格式版本未知时返回 license.ErrUnsupportedVersion
+func (v *Verifier) Decode(licenseKey string) (*license.License, error) {
 	// Base64解码
 	licenseData, err := base64.StdEncoding.DecodeString(licenseKey)
 	if err != nil {
 		return nil, license.ErrInvalidLicense
 	}
 	
-	// 提取签名和密文（RSA-4096签名长度为512字节）
-	signatureSize := 512
-	if len(licenseData) < signatureSize {
-		return nil, license.ErrInvalidLicense
+	// 解析许可证容器（兼容旧版 v0 格式）
+	envelope, err := license.ParseEnvelope(licenseData)
+	if err != nil {
+		return nil, err
 	}
 	
-	signature := licenseData[:signatureSize]
-	encryptedData := licenseData[signatureSize:]
-	
-	// 验证签名
-	valid, err := crypto.VerifySignature(encryptedData, signature, v.publicKey)
-	if err != nil || !valid {
-		return nil, license.ErrInvalidLicense
+	// 产品许可证使用从主密钥派生的产品密钥加密
+	// 加密到设备公钥的许可证无法在服务器端解密（服务器不持有设备私钥），Open 返回 license.ErrDeviceKeyRequired
+	aesKey := v.aesKey
+	if envelope.EncryptionAlgorithm == license.EncryptionAES256GCM && envelope.EncryptionKeyID != "" {
+		aesKey, err = crypto.DeriveProductKey(v.aesKey, envelope.EncryptionKeyID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	
-	// 使用AES解密
-	jsonData, err := crypto.DecryptAES(encryptedData, v.aesKey)
+	// 验证签名并解密
+	jsonData, err := envelope.Open(v.keys, aesKey)
 	if err != nil {
-		return nil, license.ErrInvalidLicense
+		return nil, err
 	}
 	
 	// 反序列化
@@ -71,30 +127,81 @@ func (v *Verifier) Verify(licenseKey string, deviceID string) (*license.VerifyRe
 		return nil, license.ErrInvalidLicense
 	}
 	
-	// 检查设备ID
-	if lic.DeviceID != deviceID {
-		return nil, license.ErrDeviceMismatch
+	return &lic, nil
+}
+
+// DecodeWithRecord 验证签名并解码客户端提交的许可证，服务器无法解密时以服务器记录为准
+// 加密到设备公钥的许可证只有设备能够解密：此时只验证签名，并要求提交的许可证与服务器记录完全一致，
+// 许可证内容（设备、有效期、功能和授权项）取自服务器记录
+// 参数：
+//   - licenseKey: base64编码的许可证密钥
+//   - record: 服务器上对应的许可证记录
+// 返回值：
+//   - *license.License: 许可证对象
+//   - error: 见 Decode；加密到设备公钥的许可证与记录不一致时返回 license.ErrInvalidLicense
+func (v *Verifier) DecodeWithRecord(licenseKey string, record *database.LicenseRecord) (*license.License, error) {
+	lic, err := v.Decode(licenseKey)
+	if !errors.Is(err, license.ErrDeviceKeyRequired) {
+		return lic, err
 	}
 	
-	// 检查是否过期
-	now := time.Now()
-	expired := now.After(lic.ExpiryDate)
-	
-	result := &license.VerifyResult{
-		Valid:       !expired,
-		Expired:     expired,
-		ExpiryDate:  lic.ExpiryDate,
-		DeviceID:    lic.DeviceID,
-		LicenseType: string(lic.LicenseType),
-		Message:     "License verified",
+	licenseData, err := base64.StdEncoding.DecodeString(licenseKey)
+	if err != nil {
+		return nil, license.ErrInvalidLicense
 	}
-	
-	if expired {
-		result.Message = "License expired"
-		return result, license.ErrExpiredLicense
+	envelope, err := license.ParseEnvelope(licenseData)
+	if err != nil {
+		return nil, err
+	}
+	if err := envelope.VerifySignature(v.keys); err != nil {
+		return nil, err
+	}
+	if record.LicenseKey != licenseKey {
+		return nil, license.ErrInvalidLicense
 	}
 	
-	return result, nil
+	return recordLicense(record)
 }
 
+// recordLicense 由服务器记录构造许可证对象（用于无法解密的许可证）
+func recordLicense(record *database.LicenseRecord) (*license.License, error) {
+	features, entitlements, err := recordEntitlements(record)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &license.License{
+		ID:               record.LicenseID,
+		DeviceID:         record.DeviceID,
+		ProductID:        record.ProductID,
+		VersionRange:     record.VersionRange,
+		ExpiryDate:       record.ExpiryDate,
+		NotBefore:        record.NotBefore,
+		Perpetual:        record.Perpetual,
+		MaintenanceUntil: record.MaintenanceUntil,
+		LicenseType:      license.LicenseType(record.LicenseType),
+		Seats:            record.Seats,
+		MaxActivations:   record.MaxActivations,
+		TrialDays:        record.TrialDays,
+		Features:         features,
+		Entitlements:     entitlements,
+		CreatedAt:        record.CreatedAt,
+	}, nil
+}
 
+// recordEntitlements 解析许可证记录中的功能列表和授权项
+func recordEntitlements(record *database.LicenseRecord) ([]string, license.Entitlements, error) {
+	var features []string
+	if record.Features != "" {
+		features = strings.Split(record.Features, ",")
+	}
+	
+	var entitlements license.Entitlements
+	if record.Entitlements != "" {
+		if err := json.Unmarshal([]byte(record.Entitlements), &entitlements); err != nil {
+			return nil, nil, fmt.Errorf("invalid entitlements in license %s: %w", record.LicenseID, err)
+		}
+	}
+	
+	return features, entitlements, nil
+}
@@ -0,0 +1,132 @@
+// Package server 提供网络授权服务器功能
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+)
+
+// activationRequest 激活和停用请求
+type activationRequest struct {
+	LicenseID  string `json:"license_id"`
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	AppID      string `json:"app_id"`
+}
+
+// handleActivate 处理多设备许可证激活请求
+// 激活数已达上限时返回 409；同一设备重复激活时返回已有的激活记录
+func (s *Server) handleActivate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, record, ok := s.parseActivationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case record.IsRevoked():
+		s.writeError(w, http.StatusForbidden, "LICENSE_REVOKED", "License revoked")
+		return
+	case record.IsExpired(time.Now()):
+		s.writeError(w, http.StatusForbidden, "LICENSE_EXPIRED", "License expired")
+		return
+	case record.IsNotYetValid(time.Now()):
+		s.writeError(w, http.StatusForbidden, "LICENSE_NOT_YET_VALID", "License not yet valid")
+		return
+	}
+
+	activation, count, err := s.db.ActivateDevice(record, req.DeviceID, req.DeviceName, req.AppID)
+	if errors.Is(err, database.ErrActivationLimit) {
+		s.writeError(w, http.StatusConflict, "ACTIVATION_LIMIT", "Activation limit reached")
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to activate device")
+		return
+	}
+
+	response := map[string]interface{}{
+		"license_id":      record.LicenseID,
+		"device_id":       activation.DeviceID,
+		"activated_at":    activation.ActivatedAt.UTC().Format(time.RFC3339),
+		"activations":     count,
+		"max_activations": record.MaxActivations,
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleDeactivate 处理停用设备请求，释放激活名额
+func (s *Server) handleDeactivate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, record, ok := s.parseActivationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// 客户端Token只能停用其应用下激活的设备
+	activation, err := s.db.GetActivation(record.ID, req.DeviceID)
+	if errors.Is(err, database.ErrNotActivated) {
+		s.writeError(w, http.StatusNotFound, "NOT_ACTIVATED", "Device not activated for this license")
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to query activation")
+		return
+	}
+	if !s.authorizeApp(w, r, activation.AppID) {
+		return
+	}
+
+	if err := s.db.DeactivateDevice(record.ID, req.DeviceID); err != nil && !errors.Is(err, database.ErrNotActivated) {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to deactivate device")
+		return
+	}
+
+	response := map[string]interface{}{
+		"license_id": record.LicenseID,
+		"device_id":  req.DeviceID,
+		"status":     "deactivated",
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// parseActivationRequest 解析激活请求并查询多设备许可证
+// 失败时写入错误响应并返回false
+func (s *Server) parseActivationRequest(w http.ResponseWriter, r *http.Request) (*activationRequest, *database.LicenseRecord, bool) {
+	var req activationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return nil, nil, false
+	}
+	if req.LicenseID == "" || req.DeviceID == "" {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "License ID and device ID required")
+		return nil, nil, false
+	}
+
+	if !s.authorizeApp(w, r, req.AppID) {
+		return nil, nil, false
+	}
+
+	record, err := s.db.GetLicenseByLicenseID(req.LicenseID)
+	if !s.authorizeLicense(w, r, record, err) {
+		return nil, nil, false
+	}
+	if record.MaxActivations <= 0 {
+		s.writeError(w, http.StatusBadRequest, "NOT_MULTI_DEVICE", "License is not a multi-device license")
+		return nil, nil, false
+	}
+
+	return &req, record, true
+}
@@ -2,31 +2,67 @@
 package server
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 	
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
 	"github.com/Zeroshcat/LicenseManager/internal/database"
+	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
 	"github.com/Zeroshcat/LicenseManager/pkg/license"
 )
 
 // Server 授权服务器
 type Server struct {
-	db      *database.DB
-	handler http.Handler
+	db          *database.DB
+	verifier    *licensegen.Verifier // 离线许可证验证器（用于双重验证）
+	crlSigner   crypto.Signer        // 吊销列表签名器（为nil时不提供CRL）
+	crlValidity time.Duration        // 吊销列表有效期
+	leaseTTL    time.Duration        // 浮动许可证席位租约有效期
+	expiry      license.ExpiryPolicy // 网络验证和双重验证的到期策略（宽限期和提醒期）
+	handler     http.Handler
 }
 
 // NewServer 创建授权服务器
 // 参数：
 //   - db: 数据库连接
+//   - verifier: 离线许可证验证器（为nil时双重验证接口不可用）
 // 返回值：
 //   - *Server: 服务器实例
-func NewServer(db *database.DB) *Server {
-	s := &Server{db: db}
+func NewServer(db *database.DB, verifier *licensegen.Verifier) *Server {
+	s := &Server{db: db, verifier: verifier, leaseTTL: DefaultLeaseTTL}
 	s.setupRoutes()
 	return s
 }
 
+// EnableCRL 启用吊销列表发布（GET /api/v1/license/crl）
+// 参数：
+//   - signer: 签发许可证使用的签名器
+//   - validity: 吊销列表有效期（客户端应在到期前更新）
+func (s *Server) EnableCRL(signer crypto.Signer, validity time.Duration) {
+	s.crlSigner = signer
+	s.crlValidity = validity
+}
+
+// SetLeaseTTL 设置浮动许可证席位租约的有效期
+// 客户端按有效期的三分之一发送心跳，超过有效期未续期的席位被回收
+// 参数：
+//   - ttl: 租约有效期
+func (s *Server) SetLeaseTTL(ttl time.Duration) {
+	s.leaseTTL = ttl
+}
+
+// SetExpiryPolicy 设置网络验证和双重验证的到期策略
+// 宽限期内网络验证和双重验证仍然成功（InGracePeriod 为true），提醒期内 ExpiresSoon 为true
+// 参数：
+//   - policy: 到期策略
+func (s *Server) SetExpiryPolicy(policy license.ExpiryPolicy) {
+	s.expiry = policy
+}
+
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
 	mux := http.NewServeMux()
@@ -34,13 +70,26 @@ func (s *Server) setupRoutes() {
 	// 健康检查
 	mux.HandleFunc("/api/health", s.handleHealth)
 	
-	// 许可证验证端点
-	mux.HandleFunc("/api/v1/license/verify/online", s.handleVerifyOnline)
-	mux.HandleFunc("/api/v1/license/verify/dual", s.handleVerifyDual)
+	// 许可证验证端点（/api/v1/* 均需要 Bearer Token）
+	mux.HandleFunc("/api/v1/license/verify/online", s.requireToken(s.handleVerifyOnline))
+	mux.HandleFunc("/api/v1/license/verify/dual", s.requireToken(s.handleVerifyDual))
+	mux.HandleFunc("/api/v1/license/crl", s.requireToken(s.handleCRL))
+	
+	// 浮动许可证席位端点
+	mux.HandleFunc("/api/v1/license/checkout", s.requireToken(s.handleCheckout))
+	mux.HandleFunc("/api/v1/license/heartbeat", s.requireToken(s.handleHeartbeat))
+	mux.HandleFunc("/api/v1/license/checkin", s.requireToken(s.handleCheckin))
+	
+	// 多设备许可证激活端点
+	mux.HandleFunc("/api/v1/license/activate", s.requireToken(s.handleActivate))
+	mux.HandleFunc("/api/v1/license/deactivate", s.requireToken(s.handleDeactivate))
+	
+	// 试用许可证端点
+	mux.HandleFunc("/api/v1/license/trial", s.requireToken(s.handleTrial))
 	
 	// 设备管理端点
-	mux.HandleFunc("/api/v1/device/register", s.handleRegisterDevice)
-	mux.HandleFunc("/api/v1/device/", s.handleGetDevice)
+	mux.HandleFunc("/api/v1/device/register", s.requireToken(s.handleRegisterDevice))
+	mux.HandleFunc("/api/v1/device/", s.requireToken(s.handleGetDevice))
 	
 	s.handler = mux
 }
@@ -84,36 +133,75 @@ func (s *Server) handleVerifyOnline(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if !s.authorizeApp(w, r, req.AppID) {
+		return
+	}
+	
 	// 查询许可证
+	// 客户端Token只能验证其应用下的许可证
 	licenseRecord, err := s.db.GetLicenseByDeviceID(req.DeviceID)
-	if err != nil {
-		s.writeError(w, http.StatusNotFound, "LICENSE_NOT_FOUND", "License not found")
+	if !s.authorizeLicense(w, r, licenseRecord, err) {
 		return
 	}
 	
-	// 检查是否过期
-	now := time.Now()
-	expired := now.After(licenseRecord.ExpiryDate)
+	// 检查是否过期（试用许可证从设备首次激活开始计算，宽限期内仍然有效）
+	expiryDate, started := s.licenseExpiry(licenseRecord, req.DeviceID)
 	
+	now := time.Now()
 	result := license.VerifyResult{
-		Valid:       !expired,
-		Expired:     expired,
-		ExpiryDate:  licenseRecord.ExpiryDate,
-		DeviceID:    req.DeviceID,
-		LicenseType: licenseRecord.LicenseType,
-		Message:     "Online verification",
+		LicenseID:        licenseRecord.LicenseID,
+		ExpiryDate:       expiryDate,
+		NotBefore:        licenseRecord.NotBefore,
+		Perpetual:        licenseRecord.Perpetual,
+		MaintenanceUntil: licenseRecord.MaintenanceUntil,
+		DeviceID:         req.DeviceID,
+		LicenseType:      licenseRecord.LicenseType,
+		Message:          "Online verification",
+	}
+	result.Features, result.Entitlements = recordEntitlements(licenseRecord)
+	s.expiry.Apply(&result, now)
+	result.Valid = started && !result.Expired && !licenseRecord.IsNotYetValid(now)
+	
+	// 已撤销的许可证无论是否过期都无效
+	if licenseRecord.IsRevoked() {
+		result.Valid = false
+		result.Revoked = true
+		result.Reason = license.ReasonLicenseRevoked
+		result.Message = "License revoked"
+		s.writeJSON(w, http.StatusOK, result)
+		return
 	}
 	
-	if expired {
+	if result.Expired {
 		result.Message = "License expired"
 		s.writeJSON(w, http.StatusOK, result)
 		return
 	}
 	
+	if licenseRecord.IsNotYetValid(now) {
+		result.Reason = license.ReasonNotYetValid
+		result.Message = "License not yet valid"
+		s.writeJSON(w, http.StatusOK, result)
+		return
+	}
+	
+	if !started {
+		result.Reason = license.ReasonTrialNotStarted
+		result.Message = "Trial not started"
+		s.writeJSON(w, http.StatusOK, result)
+		return
+	}
+	
+	if result.InGracePeriod {
+		result.Message = "License expired, in grace period"
+	}
+	
 	s.writeJSON(w, http.StatusOK, result)
 }
 
 // handleVerifyDual 处理双重验证请求
+// 离线部分：使用服务器持有的公钥和AES密钥验证签名、解密并检查设备ID与到期时间
+// 网络部分：检查数据库中该设备的许可证记录，并与离线许可证交叉核对
 func (s *Server) handleVerifyDual(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -132,35 +220,162 @@ func (s *Server) handleVerifyDual(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// 这里应该调用离线验证器验证licenseKey
-	// 简化处理，只做网络验证部分
-	_, err := s.db.GetDeviceByID(req.DeviceID)
-	if err != nil {
-		s.writeError(w, http.StatusNotFound, "DEVICE_NOT_FOUND", "Device not found")
+	if !s.authorizeApp(w, r, req.AppID) {
+		return
+	}
+	
+	// 未配置离线验证密钥时无法完成双重验证
+	if s.verifier == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "DUAL_UNAVAILABLE", "Offline verification keys are not configured")
+		return
+	}
+	
+	// 客户端Token只能验证其应用下的设备和许可证
+	deviceRecord, err := s.db.GetDeviceByID(req.DeviceID)
+	if !s.authorizeDevice(w, r, deviceRecord, err) {
 		return
 	}
 	
 	licenseRecord, err := s.db.GetLicenseByDeviceID(req.DeviceID)
-	if err != nil {
-		s.writeError(w, http.StatusNotFound, "LICENSE_NOT_FOUND", "License not found")
+	if !s.authorizeLicense(w, r, licenseRecord, err) {
 		return
 	}
 	
+	result := s.verifyDual(req.LicenseKey, req.DeviceID, licenseRecord)
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// verifyDual 执行双重验证，分别给出离线和网络验证结果
+// 参数：
+//   - licenseKey: 客户端提交的离线许可证密钥
+//   - deviceID: 设备ID
+//   - record: 数据库中该设备的许可证记录
+// 返回值：
+//   - license.VerifyResult: 验证结果（Reason 为第一个失败原因）
+func (s *Server) verifyDual(licenseKey string, deviceID string, record *database.LicenseRecord) license.VerifyResult {
 	now := time.Now()
-	expired := now.After(licenseRecord.ExpiryDate)
+	expiryDate, started := s.licenseExpiry(record, deviceID)
 	
 	result := license.VerifyResult{
-		Valid:        !expired,
-		Expired:      expired,
-		ExpiryDate:   licenseRecord.ExpiryDate,
-		DeviceID:     req.DeviceID,
-		LicenseType:  "dual",
-		OfflineValid: true, // 应该从离线验证器获取
-		OnlineValid:  !expired,
-		Message:      "Dual verification",
+		LicenseID:        record.LicenseID,
+		ExpiryDate:       expiryDate,
+		NotBefore:        record.NotBefore,
+		Perpetual:        record.Perpetual,
+		MaintenanceUntil: record.MaintenanceUntil,
+		DeviceID:         deviceID,
+		LicenseType:      string(license.LicenseTypeDual),
+	}
+	// 服务器记录的到期时间按到期策略检查（宽限期内仍然有效）
+	s.expiry.Apply(&result, now)
+	
+	// 离线验证：签名、解密、设备ID、生效时间、到期时间
+	offlineReason := ""
+	// 加密到设备公钥的许可证服务器无法解密，只验证签名并以服务器记录为准
+	lic, err := s.verifier.DecodeWithRecord(licenseKey, record)
+	// 离线许可证的到期时间同样按到期策略检查
+	var offlineExpired, offlineGrace bool
+	if err == nil {
+		offlineExpired, offlineGrace = s.checkExpiry(s.offlineExpiry(lic, record, deviceID), lic.Perpetual, now)
+	}
+	switch {
+	case err != nil:
+		offlineReason = license.ReasonOfflineInvalid
+	// 多设备和试用许可证通过服务器记录关联到设备（record 按激活或试用记录查询，网络验证核对许可证一致）
+	case !lic.MatchesDevice(deviceID):
+		offlineReason = license.ReasonOfflineDeviceMismatch
+	case lic.IsNotYetValid(now):
+		offlineReason = license.ReasonNotYetValid
+	case offlineExpired:
+		offlineReason = license.ReasonOfflineExpired
 	}
 	
-	s.writeJSON(w, http.StatusOK, result)
+	// 网络验证：服务器记录未撤销、已生效、未过期，且与离线许可证一致
+	onlineReason := ""
+	switch {
+	case record.IsRevoked():
+		onlineReason = license.ReasonLicenseRevoked
+	case record.IsNotYetValid(now):
+		onlineReason = license.ReasonNotYetValid
+	case !started:
+		onlineReason = license.ReasonTrialNotStarted
+	case result.Expired:
+		onlineReason = license.ReasonOnlineExpired
+	case record.LicenseKey != licenseKey:
+		onlineReason = license.ReasonLicenseMismatch
+	}
+	
+	// 授权项优先使用客户端提交的已签名许可证中的内容
+	if err == nil {
+		result.Features, result.Entitlements = lic.Features, lic.Entitlements
+	} else {
+		result.Features, result.Entitlements = recordEntitlements(record)
+	}
+	
+	result.OfflineValid = offlineReason == ""
+	result.OnlineValid = onlineReason == ""
+	result.Valid = result.OfflineValid && result.OnlineValid
+	result.Expired = offlineReason == license.ReasonOfflineExpired || onlineReason == license.ReasonOnlineExpired
+	result.InGracePeriod = !result.Expired && (result.InGracePeriod || offlineGrace)
+	result.Revoked = record.IsRevoked()
+	
+	// 撤销优先于离线验证结果：离线许可证本身无法感知撤销
+	switch {
+	case result.Valid && result.InGracePeriod:
+		result.Message = "License expired, in grace period"
+	case result.Valid:
+		result.Message = "Dual verification"
+	case result.Revoked:
+		result.Reason = license.ReasonLicenseRevoked
+		result.Message = "License revoked"
+	case offlineReason != "":
+		result.Reason = offlineReason
+		result.Message = "Offline verification failed"
+	default:
+		result.Reason = onlineReason
+		result.Message = "Online verification failed"
+	}
+	
+	return result
+}
+
+// checkExpiry 按到期策略检查到期时间
+// 返回值：
+//   - bool: 是否已过期（超过宽限期）
+//   - bool: 是否处于宽限期
+func (s *Server) checkExpiry(expiryDate time.Time, perpetual bool, now time.Time) (bool, bool) {
+	result := license.VerifyResult{ExpiryDate: expiryDate, Perpetual: perpetual}
+	s.expiry.Apply(&result, now)
+	return result.Expired, result.InGracePeriod
+}
+
+// handleCRL 处理吊销列表请求
+// 返回实时生成的已签名吊销列表，供离线客户端下载
+func (s *Server) handleCRL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	if s.crlSigner == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "CRL_UNAVAILABLE", "Revocation list signing key is not configured")
+		return
+	}
+	
+	records, err := s.db.ListRevokedLicenses()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to query revoked licenses")
+		return
+	}
+	
+	data, err := licensegen.GenerateCRL(licensegen.CRLEntriesFromRecords(records), s.crlValidity, s.crlSigner)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to generate revocation list")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
 }
 
 // handleRegisterDevice 处理设备注册请求
@@ -174,6 +389,7 @@ func (s *Server) handleRegisterDevice(w http.ResponseWriter, r *http.Request) {
 		DeviceID   string `json:"device_id"`
 		DeviceName string `json:"device_name"`
 		AppID      string `json:"app_id"`
+		PublicKey  string `json:"public_key"` // 设备X25519公钥（base64，可选）
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -181,15 +397,34 @@ func (s *Server) handleRegisterDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if !s.authorizeApp(w, r, req.AppID) {
+		return
+	}
+	
+	// 设备公钥用于加密签发给该设备的许可证
+	if req.PublicKey != "" {
+		publicKey, err := base64.StdEncoding.DecodeString(req.PublicKey)
+		if err != nil || len(publicKey) != 32 {
+			s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid device public key")
+			return
+		}
+	}
+	
 	// 创建设备记录
 	deviceRecord := &database.DeviceRecord{
 		DeviceID:   req.DeviceID,
 		DeviceName: req.DeviceName,
 		AppID:      req.AppID,
+		PublicKey:  req.PublicKey,
 		Status:     "active",
 	}
 	
+	// 已注册的设备不能通过此接口覆盖公钥，更换公钥需要管理员执行 device rekey
 	id, err := s.db.SaveDevice(deviceRecord)
+	if errors.Is(err, database.ErrDeviceExists) {
+		s.writeError(w, http.StatusConflict, "DEVICE_EXISTS", "Device already registered")
+		return
+	}
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to register device")
 		return
@@ -218,14 +453,17 @@ func (s *Server) handleGetDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// 客户端Token只能查看其应用下的设备（先检查Token，不泄露其他应用的设备是否存在）
 	deviceRecord, err := s.db.GetDeviceByID(deviceID)
-	if err != nil {
-		s.writeError(w, http.StatusNotFound, "DEVICE_NOT_FOUND", "Device not found")
+	if !s.authorizeDevice(w, r, deviceRecord, err) {
 		return
 	}
 	
-	// 获取许可证信息
-	licenseRecord, _ := s.db.GetLicenseByDeviceID(deviceID)
+	// 获取许可证信息（只返回Token允许访问的许可证）
+	licenseRecord, err := s.db.GetLicenseByDeviceID(deviceID)
+	if err != nil || checkApp(r, licenseRecord.AppID) != nil {
+		licenseRecord = nil
+	}
 	
 	response := map[string]interface{}{
 		"device_id":     deviceRecord.DeviceID,
@@ -236,13 +474,35 @@ func (s *Server) handleGetDevice(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	if licenseRecord != nil {
+		expiryDate, _ := s.licenseExpiry(licenseRecord, deviceID)
 		response["license_status"] = "active"
-		response["expiry_date"] = licenseRecord.ExpiryDate.Format(time.RFC3339)
+		if licenseRecord.Perpetual {
+			response["perpetual"] = true
+		} else {
+			response["expiry_date"] = expiryDate.Format(time.RFC3339)
+		}
 	}
 	
 	s.writeJSON(w, http.StatusOK, response)
 }
 
+// recordEntitlements 解析许可证记录中的功能列表和授权项（记录中的授权项无效时忽略）
+func recordEntitlements(record *database.LicenseRecord) ([]string, license.Entitlements) {
+	var features []string
+	if record.Features != "" {
+		features = strings.Split(record.Features, ",")
+	}
+	
+	var entitlements license.Entitlements
+	if record.Entitlements != "" {
+		if err := json.Unmarshal([]byte(record.Entitlements), &entitlements); err != nil {
+			entitlements = nil
+		}
+	}
+	
+	return features, entitlements
+}
+
 // writeJSON 写入JSON响应
 func (s *Server) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
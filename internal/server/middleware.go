@@ -0,0 +1,151 @@
+// Package server 提供网络授权服务器功能
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Zeroshcat/LicenseManager/internal/auth"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+)
+
+// tokenContextKey 请求上下文中保存Token记录的键
+type tokenContextKey struct{}
+
+// requireToken 要求请求携带有效的 Bearer Token
+// 验证通过后将Token记录保存到请求上下文，供处理函数检查应用ID
+// 参数：
+//   - next: 下一个处理函数
+// 返回值：
+//   - http.HandlerFunc: 包装后的处理函数
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		record, err := auth.LookupToken(s.db, bearerToken(r))
+		if err != nil {
+			s.writeTokenError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), tokenContextKey{}, record)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// authorizeApp 检查请求Token是否允许访问指定应用
+// 检查失败时写入错误响应并返回false
+// 参数：
+//   - w: 响应写入器
+//   - r: 请求（需经过 requireToken）
+//   - appID: 请求的应用ID
+// 返回值：
+//   - bool: 是否允许访问
+func (s *Server) authorizeApp(w http.ResponseWriter, r *http.Request, appID string) bool {
+	if err := checkApp(r, appID); err != nil {
+		s.writeTokenError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// checkApp 检查请求Token是否允许访问指定应用（不写入响应）
+// 参数：
+//   - r: 请求（需经过 requireToken）
+//   - appID: 请求的应用ID
+// 返回值：
+//   - error: 不允许访问的原因
+func checkApp(r *http.Request, appID string) error {
+	record, ok := r.Context().Value(tokenContextKey{}).(*database.TokenRecord)
+	if !ok {
+		return auth.ErrMissingToken
+	}
+	return auth.CheckToken(record, "", appID)
+}
+
+// authorizeLicense 检查请求Token是否允许访问查询到的许可证（按许可证所属的应用检查）
+// 查询失败时写入错误响应并返回false，见 authorizeOwner
+// 参数：
+//   - w: 响应写入器
+//   - r: 请求（需经过 requireToken）
+//   - record: 查询到的许可证记录
+//   - err: 查询错误
+// 返回值：
+//   - bool: 是否允许访问
+func (s *Server) authorizeLicense(w http.ResponseWriter, r *http.Request, record *database.LicenseRecord, err error) bool {
+	if err != nil {
+		return s.authorizeOwner(w, r, "", false, "LICENSE_NOT_FOUND", "License not found")
+	}
+	return s.authorizeOwner(w, r, record.AppID, true, "", "")
+}
+
+// authorizeDevice 检查请求Token是否允许访问查询到的设备（按设备注册时的应用检查）
+// 查询失败时写入错误响应并返回false，见 authorizeOwner
+// 参数：
+//   - w: 响应写入器
+//   - r: 请求（需经过 requireToken）
+//   - record: 查询到的设备记录
+//   - err: 查询错误
+// 返回值：
+//   - bool: 是否允许访问
+func (s *Server) authorizeDevice(w http.ResponseWriter, r *http.Request, record *database.DeviceRecord, err error) bool {
+	if err != nil {
+		return s.authorizeOwner(w, r, "", false, "DEVICE_NOT_FOUND", "Device not found")
+	}
+	return s.authorizeOwner(w, r, record.AppID, true, "", "")
+}
+
+// authorizeOwner 按记录所属的应用检查请求Token
+// 记录不存在时按不属于任何应用处理，客户端Token与访问其他应用的记录一样返回 403，
+// 不泄露记录是否存在；管理员Token返回 404
+// 参数：
+//   - w: 响应写入器
+//   - r: 请求（需经过 requireToken）
+//   - appID: 记录所属的应用ID
+//   - found: 记录是否存在
+//   - code: 记录不存在时的错误码
+//   - message: 记录不存在时的错误消息
+// 返回值：
+//   - bool: 是否允许访问
+func (s *Server) authorizeOwner(w http.ResponseWriter, r *http.Request, appID string, found bool, code, message string) bool {
+	if !s.authorizeApp(w, r, appID) {
+		return false
+	}
+	if !found {
+		s.writeError(w, http.StatusNotFound, code, message)
+		return false
+	}
+	return true
+}
+
+// bearerToken 从 Authorization 头提取 Bearer Token
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// writeTokenError 根据Token验证错误写入响应
+func (s *Server) writeTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrMissingToken):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="license"`)
+		s.writeError(w, http.StatusUnauthorized, "TOKEN_REQUIRED", "Bearer token required")
+	case errors.Is(err, auth.ErrInvalidToken):
+		s.writeError(w, http.StatusUnauthorized, "TOKEN_INVALID", "Invalid token")
+	case errors.Is(err, auth.ErrTokenRevoked):
+		s.writeError(w, http.StatusUnauthorized, "TOKEN_REVOKED", "Token has been revoked")
+	case errors.Is(err, auth.ErrTokenExpired):
+		s.writeError(w, http.StatusUnauthorized, "TOKEN_EXPIRED", "Token has expired")
+	case errors.Is(err, auth.ErrTokenTypeMismatch):
+		s.writeError(w, http.StatusForbidden, "TOKEN_FORBIDDEN", "Token type not allowed")
+	case errors.Is(err, auth.ErrAppIDMismatch):
+		s.writeError(w, http.StatusForbidden, "APP_ID_MISMATCH", "Token is not valid for this app")
+	default:
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to validate token")
+	}
+}
@@ -0,0 +1,186 @@
+// Package server 提供网络授权服务器功能
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// DefaultLeaseTTL 浮动许可证席位租约的默认有效期
+const DefaultLeaseTTL = 2 * time.Minute
+
+// handleCheckout 处理浮动许可证席位签出请求
+// 席位已满时返回 409；同一设备重复签出时续期已有租约
+func (s *Server) handleCheckout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		LicenseID string `json:"license_id"`
+		DeviceID  string `json:"device_id"`
+		AppID     string `json:"app_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+	if req.LicenseID == "" || req.DeviceID == "" {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "License ID and device ID required")
+		return
+	}
+
+	if !s.authorizeApp(w, r, req.AppID) {
+		return
+	}
+
+	record, err := s.db.GetLicenseByLicenseID(req.LicenseID)
+	if !s.authorizeLicense(w, r, record, err) {
+		return
+	}
+	if !s.checkFloatingLicense(w, record) {
+		return
+	}
+
+	lease, inUse, err := s.db.CheckoutSeat(record.LicenseID, req.DeviceID, req.AppID, record.Seats, s.leaseTTL)
+	if errors.Is(err, database.ErrNoSeatsAvailable) {
+		s.writeError(w, http.StatusConflict, "NO_SEATS_AVAILABLE", "All seats are in use")
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to check out seat")
+		return
+	}
+
+	response := s.leaseResponse(lease)
+	response["seats"] = record.Seats
+	response["seats_in_use"] = inUse
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleHeartbeat 处理席位租约续期请求
+// 许可证在租约期间被撤销或过期时释放席位并返回 403
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lease, ok := s.lookupLease(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := s.db.GetLicenseByLicenseID(lease.LicenseID)
+	if err != nil {
+		s.db.ReleaseSeat(lease.LeaseID)
+		s.writeError(w, http.StatusNotFound, "LICENSE_NOT_FOUND", "License not found")
+		return
+	}
+	if !s.checkFloatingLicense(w, record) {
+		s.db.ReleaseSeat(lease.LeaseID)
+		return
+	}
+
+	lease, err = s.db.RenewSeat(lease.LeaseID, s.leaseTTL)
+	if errors.Is(err, database.ErrLeaseNotFound) {
+		s.writeError(w, http.StatusNotFound, "LEASE_NOT_FOUND", "Seat lease not found or expired")
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to renew seat lease")
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, s.leaseResponse(lease))
+}
+
+// handleCheckin 处理席位签入请求（释放席位）
+func (s *Server) handleCheckin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lease, ok := s.lookupLease(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.ReleaseSeat(lease.LeaseID); err != nil && !errors.Is(err, database.ErrLeaseNotFound) {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to release seat")
+		return
+	}
+
+	response := map[string]interface{}{
+		"lease_id":   lease.LeaseID,
+		"license_id": lease.LicenseID,
+		"status":     "released",
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// lookupLease 解析请求中的租约ID并查询未过期的租约
+// 客户端Token只能操作其应用下的租约；失败时写入错误响应并返回false
+func (s *Server) lookupLease(w http.ResponseWriter, r *http.Request) (*database.SeatLease, bool) {
+	var req struct {
+		LeaseID string `json:"lease_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.LeaseID == "" {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return nil, false
+	}
+
+	lease, err := s.db.GetSeatLease(req.LeaseID)
+	if errors.Is(err, database.ErrLeaseNotFound) {
+		s.writeError(w, http.StatusNotFound, "LEASE_NOT_FOUND", "Seat lease not found or expired")
+		return nil, false
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to query seat lease")
+		return nil, false
+	}
+
+	if !s.authorizeApp(w, r, lease.AppID) {
+		return nil, false
+	}
+
+	return lease, true
+}
+
+// checkFloatingLicense 检查许可证是否为未撤销、未过期且已生效的浮动许可证
+// 检查失败时写入错误响应并返回false
+func (s *Server) checkFloatingLicense(w http.ResponseWriter, record *database.LicenseRecord) bool {
+	switch {
+	case record.LicenseType != string(license.LicenseTypeFloating) || record.Seats <= 0:
+		s.writeError(w, http.StatusBadRequest, "NOT_FLOATING", "License is not a floating license")
+	case record.IsRevoked():
+		s.writeError(w, http.StatusForbidden, "LICENSE_REVOKED", "License revoked")
+	case record.IsExpired(time.Now()):
+		s.writeError(w, http.StatusForbidden, "LICENSE_EXPIRED", "License expired")
+	case record.IsNotYetValid(time.Now()):
+		s.writeError(w, http.StatusForbidden, "LICENSE_NOT_YET_VALID", "License not yet valid")
+	default:
+		return true
+	}
+	return false
+}
+
+// leaseResponse 构造租约响应（heartbeat_interval 为建议的心跳间隔秒数）
+func (s *Server) leaseResponse(lease *database.SeatLease) map[string]interface{} {
+	return map[string]interface{}{
+		"lease_id":           lease.LeaseID,
+		"license_id":         lease.LicenseID,
+		"device_id":          lease.DeviceID,
+		"expires_at":         lease.ExpiresAt.UTC().Format(time.RFC3339),
+		"heartbeat_interval": int((s.leaseTTL / 3).Seconds()),
+	}
+}
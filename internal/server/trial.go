@@ -0,0 +1,122 @@
+// Package server 提供网络授权服务器功能
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/pkg/license"
+)
+
+// handleTrial 处理开始试用请求
+// 首次请求时记录设备的首次激活时间；同一设备重复请求时返回已有的试用记录，
+// 设备已试用过其他试用许可证时返回 409（每台设备只能试用一次）
+func (s *Server) handleTrial(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req activationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+	if req.LicenseID == "" || req.DeviceID == "" {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "License ID and device ID required")
+		return
+	}
+
+	if !s.authorizeApp(w, r, req.AppID) {
+		return
+	}
+
+	record, err := s.db.GetLicenseByLicenseID(req.LicenseID)
+	if !s.authorizeLicense(w, r, record, err) {
+		return
+	}
+
+	switch {
+	case record.LicenseType != string(license.LicenseTypeTrial):
+		s.writeError(w, http.StatusBadRequest, "NOT_TRIAL", "License is not a trial license")
+		return
+	// 指定了设备ID的试用许可证只能在该设备上试用
+	case record.DeviceID != "" && record.DeviceID != req.DeviceID:
+		s.writeError(w, http.StatusForbidden, "DEVICE_MISMATCH", "Trial license is bound to another device")
+		return
+	case record.IsRevoked():
+		s.writeError(w, http.StatusForbidden, "LICENSE_REVOKED", "License revoked")
+		return
+	case record.IsExpired(time.Now()):
+		s.writeError(w, http.StatusForbidden, "LICENSE_EXPIRED", "License expired")
+		return
+	case record.IsNotYetValid(time.Now()):
+		s.writeError(w, http.StatusForbidden, "LICENSE_NOT_YET_VALID", "License not yet valid")
+		return
+	}
+
+	device, err := s.db.StartTrial(record, req.DeviceID, req.DeviceName, req.AppID)
+	if errors.Is(err, database.ErrTrialUsed) {
+		s.writeError(w, http.StatusConflict, "TRIAL_USED", "Trial already used on this device")
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "SERVER_ERROR", "Failed to start trial")
+		return
+	}
+
+	startedAt := device.TrialStartedAt.UTC()
+	response := map[string]interface{}{
+		"license_id":  record.LicenseID,
+		"device_id":   device.DeviceID,
+		"started_at":  startedAt.Format(time.RFC3339),
+		"expiry_date": license.TrialExpiry(startedAt, record.TrialDays, record.ExpiryDate).Format(time.RFC3339),
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// trialStartedAt 查询设备开始试用许可证的时间
+// 返回值：
+//   - time.Time: 首次激活时间
+//   - bool: 设备是否已开始试用该许可证
+func (s *Server) trialStartedAt(record *database.LicenseRecord, deviceID string) (time.Time, bool) {
+	device, err := s.db.GetDeviceByID(deviceID)
+	if err != nil || device.TrialLicenseID != record.LicenseID || device.TrialStartedAt == nil {
+		return time.Time{}, false
+	}
+	return *device.TrialStartedAt, true
+}
+
+// licenseExpiry 计算许可证记录在设备上的实际到期时间
+// 试用许可证从设备首次激活开始计算，其他许可证为记录的到期时间
+// 返回值：
+//   - time.Time: 实际到期时间
+//   - bool: 许可证是否已在设备上生效（试用许可证未开始试用时为false）
+func (s *Server) licenseExpiry(record *database.LicenseRecord, deviceID string) (time.Time, bool) {
+	if record.LicenseType != string(license.LicenseTypeTrial) {
+		return record.ExpiryDate, true
+	}
+
+	startedAt, ok := s.trialStartedAt(record, deviceID)
+	if !ok {
+		return record.ExpiryDate, false
+	}
+	return license.TrialExpiry(startedAt, record.TrialDays, record.ExpiryDate), true
+}
+
+// offlineExpiry 计算离线许可证在设备上的实际到期时间
+// 试用许可证的首次激活时间以服务器记录为准，未开始试用时由网络验证给出 TRIAL_NOT_STARTED
+func (s *Server) offlineExpiry(lic *license.License, record *database.LicenseRecord, deviceID string) time.Time {
+	if !lic.IsTrial() {
+		return lic.ExpiryDate
+	}
+
+	startedAt, ok := s.trialStartedAt(record, deviceID)
+	if !ok {
+		return lic.ExpiryDate
+	}
+	return lic.TrialExpiry(startedAt)
+}
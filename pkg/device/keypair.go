@@ -0,0 +1,61 @@
+// Package device 提供设备ID获取和硬件指纹识别功能
+package device
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// KeyPair 设备密钥对（X25519）
+// 私钥只保存在设备上，公钥在设备注册时提交给授权服务器，服务器签发的许可证加密到该公钥
+type KeyPair struct {
+	PrivateKey []byte // 私钥（32字节）
+	PublicKey  []byte // 公钥（32字节）
+}
+
+// PublicKeyBase64 返回base64编码的公钥（设备注册请求中的 public_key 字段）
+func (k *KeyPair) PublicKeyBase64() string {
+	return base64.StdEncoding.EncodeToString(k.PublicKey)
+}
+
+// LoadOrCreateKeyPair 加载设备密钥对，文件不存在时生成新的密钥对并保存（首次运行）
+// 参数：
+//   - path: 私钥文件路径（仅所有者可读）
+//
+// 返回值：
+//   - *KeyPair: 设备密钥对
+//   - error: 加载或生成过程中的错误
+func LoadOrCreateKeyPair(path string) (*KeyPair, error) {
+	privateKey, err := os.ReadFile(path)
+	if err == nil {
+		publicKey, err := crypto.X25519PublicKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device key %s: %w", path, err)
+		}
+		return &KeyPair{PrivateKey: privateKey, PublicKey: publicKey}, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read device key: %w", err)
+	}
+
+	privateKey, publicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate device key: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create device key directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, privateKey, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write device key: %w", err)
+	}
+
+	return &KeyPair{PrivateKey: privateKey, PublicKey: publicKey}, nil
+}
@@ -0,0 +1,133 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultClockTolerance 默认的时钟回拨容差（允许NTP校时等小幅回调）
+const DefaultClockTolerance = 5 * time.Minute
+
+// clockFile 时钟状态文件名
+const clockFile = "clock.json"
+
+// clockState 时钟状态（保存在客户端状态目录中）
+type clockState struct {
+	LastSeen time.Time `json:"last_seen"`          // 验证成功时见到的最晚时间
+	Licenses []string  `json:"licenses,omitempty"` // 验证成功过的许可证ID（判断其他状态文件是否被删除）
+}
+
+// EnableClockCheck 启用时钟回拨检测
+// 每次验证成功后在状态目录中记录见到的最晚时间，系统时间比该时间回退超过容差时验证返回 ErrClockTampered；
+// 需要先调用 SetStateDir 设置状态目录，验证器需要持有AES密钥或设备私钥（见 SetDeviceKey）。
+// 系统时间早于许可证的签发时间时同样返回 ErrClockTampered；已经见过的试用许可证的时钟状态或试用状态缺失时视为回拨，
+// 分别返回 ErrClockTampered 和 ErrStateTampered，而不是重新开始。
+// 局限：状态文件的MAC密钥由随客户端分发的AES密钥或设备私钥派生，持有该密钥的人可以伪造状态文件；
+// 删除整个状态目录仍会重置检测。需要严格限制时应配合网络验证或双重验证使用
+// 参数：
+//   - tolerance: 允许的时钟回退（小于等于0时使用 DefaultClockTolerance）
+//
+// 返回值：
+//   - error: 未设置状态目录时返回 ErrStateRequired，只持有公钥时返回 ErrStateKeyRequired
+func (v *OfflineVerifier) EnableClockCheck(tolerance time.Duration) error {
+	if v.stateDir == "" {
+		return ErrStateRequired
+	}
+	if !v.hasStateKey() {
+		return ErrStateKeyRequired
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultClockTolerance
+	}
+
+	v.clockCheck = true
+	v.clockTolerance = tolerance
+	return nil
+}
+
+// checkClock 检查系统时间是否回拨（未启用时钟回拨检测时不检查）
+// 参数：
+//   - now: 当前时间
+//   - lic: 待验证的许可证
+//   - deviceID: 设备ID
+//
+// 返回值：
+//   - error: 时钟回拨或时钟状态被删除时返回 ErrClockTampered，状态文件被篡改时返回 ErrStateTampered
+func (v *OfflineVerifier) checkClock(now time.Time, lic *License, deviceID string) error {
+	if !v.clockCheck {
+		return nil
+	}
+
+	// 许可证签发之前不可能验证许可证
+	if !lic.CreatedAt.IsZero() && now.Before(lic.CreatedAt.Add(-v.clockTolerance)) {
+		return fmt.Errorf("%w: before license issue time %s", ErrClockTampered, lic.CreatedAt.Format(time.RFC3339))
+	}
+
+	state, found, _, err := v.loadClock()
+	if err != nil {
+		return err
+	}
+	if !found {
+		if !lic.IsTrial() {
+			return nil
+		}
+
+		// 启用检测时记录过的试用状态还在，时钟状态却不存在：时钟状态被删除
+		trial, trialFound, err := v.loadTrial(lic, deviceID)
+		if err != nil {
+			return err
+		}
+		if trialFound && trial.ClockCheck {
+			return fmt.Errorf("%w: clock state missing", ErrClockTampered)
+		}
+		return nil
+	}
+	if now.Before(state.LastSeen.Add(-v.clockTolerance)) {
+		return fmt.Errorf("%w: last seen %s", ErrClockTampered, state.LastSeen.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// recordClock 验证成功后记录见到的最晚时间（时间只前进不后退）和许可证ID
+func (v *OfflineVerifier) recordClock(now time.Time, lic *License) error {
+	if !v.clockCheck {
+		return nil
+	}
+
+	state, _, store, err := v.loadClock()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if now.After(state.LastSeen) {
+		state.LastSeen = now.UTC()
+		changed = true
+	}
+	if lic.ID != "" && !slices.Contains(state.Licenses, lic.ID) {
+		state.Licenses = append(state.Licenses, lic.ID)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return store.save(clockFile, state)
+}
+
+// loadClock 读取时钟状态（状态文件不存在时返回零值）
+// 时钟状态属于验证器而非设备，MAC不绑定设备ID
+func (v *OfflineVerifier) loadClock() (*clockState, bool, *stateStore, error) {
+	store, err := v.stateStore("")
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	var state clockState
+	found, err := store.load(clockFile, &state)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return &state, found, store, nil
+}
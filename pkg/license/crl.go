@@ -0,0 +1,184 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CRLEntry 吊销列表条目
+type CRLEntry struct {
+	Serial    string    `json:"serial"`           // 许可证序列号（许可证ID，旧版许可证为 LicenseSerial 指纹）
+	RevokedAt time.Time `json:"revoked_at"`       // 撤销时间
+	Reason    string    `json:"reason,omitempty"` // 撤销原因
+}
+
+// CRL 许可证吊销列表
+// 由服务器使用签发许可证的RSA私钥签名，供离线客户端拒绝已撤销的许可证
+type CRL struct {
+	IssuedAt   time.Time  `json:"issued_at"`   // 签发时间
+	NextUpdate time.Time  `json:"next_update"` // 下次更新时间（超过后视为过期）
+	Entries    []CRLEntry `json:"entries"`     // 已撤销的许可证
+}
+
+// crlSignaturePrefix 吊销列表签名的域分隔前缀
+// 吊销列表与许可证使用同一个签名密钥，前缀保证CRL签名不能被当作其他数据的签名使用，反之亦然
+const crlSignaturePrefix = "LMLK-CRL\x00"
+
+// SignedCRL 已签名的吊销列表（CRL文件格式）
+// Payload 为 CRL 的JSON序列化结果，Signature 为对 SignedData（域分隔前缀 + Payload）的签名
+type SignedCRL struct {
+	KeyID     string `json:"key_id,omitempty"` // 签名密钥ID
+	Payload   []byte `json:"payload"`          // CRL JSON（base64编码）
+	Signature []byte `json:"signature"`        // 签名（base64编码）
+}
+
+// SignedData 返回签名覆盖的数据（域分隔前缀 "LMLK-CRL\x00" + Payload）
+// 返回值：
+//   - []byte: 待签名或待验证的数据
+func (s *SignedCRL) SignedData() []byte {
+	data := make([]byte, 0, len(crlSignaturePrefix)+len(s.Payload))
+	data = append(data, crlSignaturePrefix...)
+	return append(data, s.Payload...)
+}
+
+// IsRevoked 检查序列号是否在吊销列表中
+// 参数：
+//   - serial: 许可证序列号
+//
+// 返回值：
+//   - *CRLEntry: 吊销条目（未吊销时为nil）
+func (c *CRL) IsRevoked(serial string) *CRLEntry {
+	for i := range c.Entries {
+		if c.Entries[i].Serial == serial {
+			return &c.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Stale 检查吊销列表是否已超过下次更新时间
+// 参数：
+//   - now: 当前时间
+//
+// 返回值：
+//   - bool: 是否过期
+func (c *CRL) Stale(now time.Time) bool {
+	return now.After(c.NextUpdate)
+}
+
+// LicenseSerial 计算许可证序列号
+// 序列号为许可证二进制内容的SHA-256指纹，对已签发的许可证保持稳定
+// 参数：
+//   - licenseKey: base64编码的许可证密钥
+//
+// 返回值：
+//   - string: 十六进制序列号
+//   - error: 许可证无法解码时的错误
+func LicenseSerial(licenseKey string) (string, error) {
+	licenseData, err := base64.StdEncoding.DecodeString(licenseKey)
+	if err != nil {
+		return "", ErrInvalidLicense
+	}
+
+	hash := sha256.Sum256(licenseData)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// ParseCRL 解析并验证已签名的吊销列表
+// 参数：
+//   - data: CRL文件内容（SignedCRL 的JSON）
+//   - keys: 受信任的签名公钥集合
+//
+// 返回值：
+//   - *CRL: 吊销列表
+//   - error: 格式错误或签名无效时返回 ErrInvalidCRL
+func ParseCRL(data []byte, keys *KeySet) (*CRL, error) {
+	var signed SignedCRL
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCRL, err)
+	}
+
+	if err := keys.Verify(signed.KeyID, "", signed.SignedData(), signed.Signature); err != nil {
+		return nil, fmt.Errorf("%w: signature verification failed", ErrInvalidCRL)
+	}
+
+	var crl CRL
+	if err := json.Unmarshal(signed.Payload, &crl); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCRL, err)
+	}
+
+	return &crl, nil
+}
+
+// LoadCRL 从文件加载吊销列表，之后的验证会拒绝列表中的许可证
+// 参数：
+//   - path: CRL文件路径
+//   - strict: 严格模式，CRL超过下次更新时间后拒绝所有许可证
+//
+// 返回值：
+//   - error: 加载过程中的错误
+func (v *OfflineVerifier) LoadCRL(path string, strict bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CRL file: %w", err)
+	}
+
+	return v.SetCRL(data, strict)
+}
+
+// SetCRL 设置吊销列表（例如从嵌入资源或网络获取的CRL）
+// 参数：
+//   - data: CRL文件内容
+//   - strict: 严格模式，CRL超过下次更新时间后拒绝所有许可证
+//
+// 返回值：
+//   - error: CRL无效时的错误
+func (v *OfflineVerifier) SetCRL(data []byte, strict bool) error {
+	crl, err := ParseCRL(data, v.keys)
+	if err != nil {
+		return err
+	}
+
+	v.crl = crl
+	v.crlStrict = strict
+	return nil
+}
+
+// checkCRL 根据吊销列表检查许可证
+// 优先按许可证ID匹配，旧版许可证（无ID）按许可证指纹匹配
+// 参数：
+//   - lic: 已解码的许可证
+//   - licenseKey: base64编码的许可证密钥
+//   - now: 当前时间
+//
+// 返回值：
+//   - *CRLEntry: 吊销条目（未吊销时为nil）
+//   - error: 严格模式下CRL过期时返回 ErrCRLExpired
+func (v *OfflineVerifier) checkCRL(lic *License, licenseKey string, now time.Time) (*CRLEntry, error) {
+	if v.crl == nil {
+		return nil, nil
+	}
+
+	if v.crlStrict && v.crl.Stale(now) {
+		return nil, ErrCRLExpired
+	}
+
+	if lic.ID != "" {
+		if entry := v.crl.IsRevoked(lic.ID); entry != nil {
+			return entry, nil
+		}
+	}
+
+	serial, err := LicenseSerial(licenseKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.crl.IsRevoked(serial), nil
+}
@@ -0,0 +1,75 @@
+package license
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCRL(t *testing.T) {
+	signer := newTestSigner(t)
+	keys := newTestKeySet(t, signer)
+
+	crl := CRL{
+		IssuedAt:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NextUpdate: time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+		Entries:    []CRLEntry{{Serial: "lic-1", RevokedAt: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)}},
+	}
+	payload, err := json.Marshal(crl)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sign    func(signed *SignedCRL) []byte
+		modify  func(signed *SignedCRL)
+		wantErr bool
+	}{
+		{"valid", func(signed *SignedCRL) []byte { return signed.SignedData() }, nil, false},
+		// 没有域分隔前缀的签名（例如对同一数据的其他用途的签名）无效
+		{"unprefixed signature", func(signed *SignedCRL) []byte { return signed.Payload }, nil, true},
+		{"modified payload", func(signed *SignedCRL) []byte { return signed.SignedData() }, func(signed *SignedCRL) {
+			signed.Payload = []byte(`{"entries":[]}`)
+		}, true},
+		{"unknown key", func(signed *SignedCRL) []byte { return signed.SignedData() }, func(signed *SignedCRL) {
+			signed.KeyID = "0000000000000000"
+		}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			signed := &SignedCRL{KeyID: testKeyID(t, signer), Payload: payload}
+			signature, err := signer.Sign(tc.sign(signed))
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			signed.Signature = signature
+			if tc.modify != nil {
+				tc.modify(signed)
+			}
+			data, err := json.Marshal(signed)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			got, err := ParseCRL(data, keys)
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidCRL) {
+					t.Fatalf("ParseCRL: err = %v, want ErrInvalidCRL", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCRL: %v", err)
+			}
+			if got.IsRevoked("lic-1") == nil || got.IsRevoked("lic-2") != nil {
+				t.Fatalf("ParseCRL entries = %+v", got.Entries)
+			}
+		})
+	}
+
+	if _, err := ParseCRL([]byte("crl"), keys); !errors.Is(err, ErrInvalidCRL) {
+		t.Fatalf("ParseCRL invalid JSON: err = %v, want ErrInvalidCRL", err)
+	}
+}
@@ -1,13 +1,24 @@
 // Package license 提供许可证生成和验证功能
 package license
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // DualConfig 双重验证配置
 type DualConfig struct {
-	APIURL  string // API地址（必须）
-	AppID   string // 应用ID（必须）
-	Timeout int    // 超时时间（秒）
+	APIURL         string        // API地址（必须）
+	AppID          string        // 应用ID（必须）
+	Token          string        // API Token（作为 Bearer Token 发送）
+	Timeout        int           // 超时时间（秒）
+	ProductID      string        // 产品ID（设置后 aesKey 为产品密钥，且只接受签发给该产品的许可证，见 SetProduct）
+	ProductVersion string        // 当前产品版本（许可证限制了版本范围时需要）
+	StateDir       string        // 客户端状态目录（验证试用许可证和检测时钟回拨时需要，见 OfflineVerifier.SetStateDir）
+	ClockCheck     bool          // 离线验证时检测时钟回拨（需要 StateDir，见 OfflineVerifier.EnableClockCheck）
+	ClockTolerance time.Duration // 允许的时钟回退（为0时使用 DefaultClockTolerance）
+	ExpiryPolicy   ExpiryPolicy  // 离线验证的到期策略（网络验证的宽限期和提醒期由服务器配置）
 }
 
 // DualVerifier 双重验证器
@@ -21,21 +32,47 @@ type DualVerifier struct {
 // 参数：
 //   - config: 双重验证配置
 //   - publicKeyPEM: RSA公钥（PEM格式，用于离线验证）
-//   - aesKey: AES密钥（32字节，用于解密）
+//   - aesKey: AES密钥（32字节，用于解密；设置 config.ProductID 时为产品密钥）
 // 返回值：
 //   - *DualVerifier: 双重验证器实例
 //   - error: 创建过程中的错误
 func NewDualVerifier(config *DualConfig, publicKeyPEM []byte, aesKey []byte) (*DualVerifier, error) {
 	// 创建离线验证器
-	offlineVerifier, err := NewOfflineVerifier(publicKeyPEM, aesKey)
+	var offlineVerifier *OfflineVerifier
+	var err error
+	if config.ProductID != "" {
+		offlineVerifier, err = NewProductVerifier(publicKeyPEM, config.ProductID, aesKey)
+	} else {
+		offlineVerifier, err = NewOfflineVerifier(publicKeyPEM, aesKey)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create offline verifier: %w", err)
 	}
+	if config.ProductID != "" {
+		if err := offlineVerifier.SetProduct(config.ProductID, config.ProductVersion); err != nil {
+			return nil, fmt.Errorf("failed to create offline verifier: %w", err)
+		}
+	}
+	
+	if config.StateDir != "" {
+		if err := offlineVerifier.SetStateDir(config.StateDir); err != nil {
+			return nil, fmt.Errorf("failed to create offline verifier: %w", err)
+		}
+	}
+	if err := offlineVerifier.SetExpiryPolicy(config.ExpiryPolicy); err != nil {
+		return nil, fmt.Errorf("failed to create offline verifier: %w", err)
+	}
+	if config.ClockCheck {
+		if err := offlineVerifier.EnableClockCheck(config.ClockTolerance); err != nil {
+			return nil, fmt.Errorf("failed to create offline verifier: %w", err)
+		}
+	}
 	
 	// 创建网络验证器
 	onlineConfig := &OnlineConfig{
 		APIURL:  config.APIURL,
 		AppID:   config.AppID,
+		Token:   config.Token,
 		Timeout: config.Timeout,
 	}
 	onlineVerifier := NewOnlineVerifier(onlineConfig)
@@ -69,26 +106,40 @@ func (v *DualVerifier) Verify(licenseKey string, deviceID string) (*VerifyResult
 	// 执行网络验证
 	onlineResult, err := v.onlineVerifier.Verify(deviceID)
 	if err != nil {
-		return &VerifyResult{
+		result := &VerifyResult{
 			Valid:        false,
 			OfflineValid: offlineResult.Valid,
 			OnlineValid:  false,
 			Message:      "Online verification failed",
-		}, err
+		}
+		if errors.Is(err, ErrRevokedLicense) {
+			result.Revoked = true
+			result.Reason = ReasonLicenseRevoked
+			result.Message = "License revoked"
+		}
+		return result, err
 	}
 	
 	// 两者都必须通过
 	valid := offlineResult.Valid && onlineResult.Valid && !offlineResult.Expired && !onlineResult.Expired
 	
 	result := &VerifyResult{
-		Valid:        valid,
-		Expired:      offlineResult.Expired || onlineResult.Expired,
-		ExpiryDate:   offlineResult.ExpiryDate,
-		DeviceID:     deviceID,
-		LicenseType:  "dual",
-		OfflineValid: offlineResult.Valid && !offlineResult.Expired,
-		OnlineValid:  onlineResult.Valid && !onlineResult.Expired,
-		Message:      "Dual verification",
+		Valid:            valid,
+		Expired:          offlineResult.Expired || onlineResult.Expired,
+		InGracePeriod:    offlineResult.InGracePeriod || onlineResult.InGracePeriod,
+		ExpiresSoon:      offlineResult.ExpiresSoon || onlineResult.ExpiresSoon,
+		DaysRemaining:    offlineResult.DaysRemaining,
+		ExpiryDate:       offlineResult.ExpiryDate,
+		NotBefore:        offlineResult.NotBefore,
+		Perpetual:        offlineResult.Perpetual,
+		MaintenanceUntil: offlineResult.MaintenanceUntil,
+		DeviceID:         deviceID,
+		LicenseType:      "dual",
+		Features:         offlineResult.Features,
+		Entitlements:     offlineResult.Entitlements,
+		OfflineValid:     offlineResult.Valid && !offlineResult.Expired,
+		OnlineValid:      onlineResult.Valid && !onlineResult.Expired,
+		Message:          "Dual verification",
 	}
 	
 	if !valid {
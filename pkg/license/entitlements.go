@@ -0,0 +1,232 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EntitlementType 授权项类型
+type EntitlementType string
+
+const (
+	// EntitlementFlag 功能开关（例如 export=true）
+	EntitlementFlag EntitlementType = "flag"
+
+	// EntitlementLimit 数量限制（例如 max_users=50）
+	EntitlementLimit EntitlementType = "limit"
+
+	// EntitlementString 字符串值（例如 tier=pro）
+	EntitlementString EntitlementType = "string"
+)
+
+// Entitlement 授权项
+// 在许可证JSON中按类型序列化为 true/false、整数或字符串
+type Entitlement struct {
+	Type  EntitlementType // 类型
+	Flag  bool            // 功能开关（EntitlementFlag）
+	Limit int64           // 数量限制（EntitlementLimit）
+	Value string          // 字符串值（EntitlementString）
+}
+
+// FlagEntitlement 创建功能开关授权项
+func FlagEntitlement(enabled bool) Entitlement {
+	return Entitlement{Type: EntitlementFlag, Flag: enabled}
+}
+
+// LimitEntitlement 创建数量限制授权项
+func LimitEntitlement(limit int64) Entitlement {
+	return Entitlement{Type: EntitlementLimit, Limit: limit}
+}
+
+// StringEntitlement 创建字符串授权项
+func StringEntitlement(value string) Entitlement {
+	return Entitlement{Type: EntitlementString, Value: value}
+}
+
+// MarshalJSON 按类型序列化授权项
+func (e Entitlement) MarshalJSON() ([]byte, error) {
+	switch e.Type {
+	case EntitlementFlag:
+		return json.Marshal(e.Flag)
+	case EntitlementLimit:
+		return json.Marshal(e.Limit)
+	case EntitlementString:
+		return json.Marshal(e.Value)
+	default:
+		return nil, fmt.Errorf("unknown entitlement type: %q", e.Type)
+	}
+}
+
+// UnmarshalJSON 根据JSON值的类型解析授权项
+func (e *Entitlement) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*e = FlagEntitlement(v)
+	case json.Number:
+		limit, err := v.Int64()
+		if err != nil {
+			return fmt.Errorf("entitlement limit must be an integer: %s", v)
+		}
+		*e = LimitEntitlement(limit)
+	case string:
+		*e = StringEntitlement(v)
+	default:
+		return fmt.Errorf("unsupported entitlement value: %s", data)
+	}
+	return nil
+}
+
+// String 返回授权项的值
+func (e Entitlement) String() string {
+	switch e.Type {
+	case EntitlementFlag:
+		return strconv.FormatBool(e.Flag)
+	case EntitlementLimit:
+		return strconv.FormatInt(e.Limit, 10)
+	default:
+		return e.Value
+	}
+}
+
+// Entitlements 授权项集合（名称 -> 授权项）
+type Entitlements map[string]Entitlement
+
+// String 按名称排序返回授权项，格式与 ParseEntitlements 的输入一致（例如 export=true,max_users=50）
+func (e Entitlements) String() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		items = append(items, name+"="+e[name].String())
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseEntitlements 解析授权项
+// 每一项的格式为 name（开启功能）、name=true|false（功能开关）、name=<整数>（数量限制）或 name=<字符串>
+// 参数：
+//   - items: 授权项列表，例如 ["export", "max_users=50", "tier=pro"]
+//
+// 返回值：
+//   - Entitlements: 授权项集合（items 为空时返回 nil）
+//   - error: 格式错误或名称重复时的错误
+func ParseEntitlements(items []string) (Entitlements, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	entitlements := make(Entitlements, len(items))
+	for _, item := range items {
+		name, value, hasValue := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if name == "" {
+			return nil, fmt.Errorf("invalid entitlement %q: name is empty", item)
+		}
+		if _, exists := entitlements[name]; exists {
+			return nil, fmt.Errorf("duplicate entitlement: %s", name)
+		}
+
+		switch {
+		case !hasValue:
+			entitlements[name] = FlagEntitlement(true)
+		case value == "true" || value == "false":
+			entitlements[name] = FlagEntitlement(value == "true")
+		default:
+			if limit, err := strconv.ParseInt(value, 10, 64); err == nil {
+				entitlements[name] = LimitEntitlement(limit)
+			} else {
+				entitlements[name] = StringEntitlement(value)
+			}
+		}
+	}
+	return entitlements, nil
+}
+
+// HasFeature 判断功能开关是否开启
+func (e Entitlements) HasFeature(name string) bool {
+	entitlement, ok := e[name]
+	return ok && entitlement.Type == EntitlementFlag && entitlement.Flag
+}
+
+// Limit 返回数量限制
+// 返回值：
+//   - int64: 限制值
+//   - bool: 许可证是否包含该数量限制
+func (e Entitlements) Limit(name string) (int64, bool) {
+	entitlement, ok := e[name]
+	if !ok || entitlement.Type != EntitlementLimit {
+		return 0, false
+	}
+	return entitlement.Limit, true
+}
+
+// Value 返回字符串授权项（例如版本等级）
+// 返回值：
+//   - string: 授权项的值
+//   - bool: 许可证是否包含该字符串授权项
+func (e Entitlements) Value(name string) (string, bool) {
+	entitlement, ok := e[name]
+	if !ok || entitlement.Type != EntitlementString {
+		return "", false
+	}
+	return entitlement.Value, true
+}
+
+// HasFeature 判断许可证是否包含功能（功能列表或开启的功能开关）
+func (l *License) HasFeature(name string) bool {
+	return hasFeature(l.Features, l.Entitlements, name)
+}
+
+// Limit 返回许可证中的数量限制，见 Entitlements.Limit
+func (l *License) Limit(name string) (int64, bool) {
+	return l.Entitlements.Limit(name)
+}
+
+// Value 返回许可证中的字符串授权项，见 Entitlements.Value
+func (l *License) Value(name string) (string, bool) {
+	return l.Entitlements.Value(name)
+}
+
+// HasFeature 判断验证通过的许可证是否包含功能（功能列表或开启的功能开关）
+// 验证失败时结果中的授权信息不可信，调用前应先检查验证错误
+func (r *VerifyResult) HasFeature(name string) bool {
+	return hasFeature(r.Features, r.Entitlements, name)
+}
+
+// Limit 返回验证通过的许可证中的数量限制，见 Entitlements.Limit
+func (r *VerifyResult) Limit(name string) (int64, bool) {
+	return r.Entitlements.Limit(name)
+}
+
+// Value 返回验证通过的许可证中的字符串授权项，见 Entitlements.Value
+func (r *VerifyResult) Value(name string) (string, bool) {
+	return r.Entitlements.Value(name)
+}
+
+// hasFeature 在功能列表和授权项中查找功能
+func hasFeature(features []string, entitlements Entitlements, name string) bool {
+	for _, feature := range features {
+		if feature == name {
+			return true
+		}
+	}
+	return entitlements.HasFeature(name)
+}
@@ -0,0 +1,144 @@
+package license
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseEntitlements(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []string
+		want    Entitlements
+		wantErr bool
+	}{
+		{"empty", nil, nil, false},
+		{"bare name", []string{"export"}, Entitlements{"export": FlagEntitlement(true)}, false},
+		{"flags", []string{"export=true", "beta=false"}, Entitlements{
+			"export": FlagEntitlement(true),
+			"beta":   FlagEntitlement(false),
+		}, false},
+		{"limit", []string{"max_users=50"}, Entitlements{"max_users": LimitEntitlement(50)}, false},
+		{"negative limit", []string{"offset=-1"}, Entitlements{"offset": LimitEntitlement(-1)}, false},
+		{"string", []string{"tier=pro"}, Entitlements{"tier": StringEntitlement("pro")}, false},
+		{"empty string", []string{"tier="}, Entitlements{"tier": StringEntitlement("")}, false},
+		{"value with equals", []string{"query=a=b"}, Entitlements{"query": StringEntitlement("a=b")}, false},
+		{"float is string", []string{"ratio=1.5"}, Entitlements{"ratio": StringEntitlement("1.5")}, false},
+		{"whitespace", []string{" export ", " max_users = 5 "}, Entitlements{
+			"export":    FlagEntitlement(true),
+			"max_users": LimitEntitlement(5),
+		}, false},
+		{"empty name", []string{"=true"}, nil, true},
+		{"blank name", []string{"  "}, nil, true},
+		{"duplicate", []string{"export", "export=false"}, nil, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseEntitlements(tc.items)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseEntitlements(%q): err = %v, want error %v", tc.items, err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("ParseEntitlements(%q) = %v, want %v", tc.items, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEntitlementJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Entitlement
+		wantErr bool
+	}{
+		{"true", `true`, FlagEntitlement(true), false},
+		{"false", `false`, FlagEntitlement(false), false},
+		{"integer", `50`, LimitEntitlement(50), false},
+		{"large integer", `9007199254740993`, LimitEntitlement(9007199254740993), false},
+		{"string", `"pro"`, StringEntitlement("pro"), false},
+		{"numeric string", `"50"`, StringEntitlement("50"), false},
+		{"float", `1.5`, Entitlement{}, true},
+		{"null", `null`, Entitlement{}, true},
+		{"array", `[1]`, Entitlement{}, true},
+		{"object", `{}`, Entitlement{}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Entitlement
+			err := json.Unmarshal([]byte(tc.json), &got)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unmarshal(%s): err = %v, want error %v", tc.json, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got != tc.want {
+				t.Fatalf("Unmarshal(%s) = %+v, want %+v", tc.json, got, tc.want)
+			}
+
+			// 序列化后与输入一致
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tc.json {
+				t.Fatalf("Marshal = %s, want %s", data, tc.json)
+			}
+		})
+	}
+
+	if _, err := json.Marshal(Entitlement{Type: "unknown"}); err == nil {
+		t.Fatal("Marshal unknown type succeeded, want error")
+	}
+}
+
+func TestEntitlementsLookup(t *testing.T) {
+	entitlements, err := ParseEntitlements([]string{"export", "beta=false", "max_users=50", "tier=pro"})
+	if err != nil {
+		t.Fatalf("ParseEntitlements: %v", err)
+	}
+	if got := entitlements.String(); got != "beta=false,export=true,max_users=50,tier=pro" {
+		t.Fatalf("String = %q", got)
+	}
+
+	lic := &License{Features: []string{"reports"}, Entitlements: entitlements}
+	for _, tc := range []struct {
+		feature string
+		want    bool
+	}{
+		{"export", true},
+		{"reports", true},
+		{"beta", false},
+		{"max_users", false},
+		{"tier", false},
+		{"missing", false},
+	} {
+		if got := lic.HasFeature(tc.feature); got != tc.want {
+			t.Fatalf("HasFeature(%q) = %v, want %v", tc.feature, got, tc.want)
+		}
+	}
+
+	if limit, ok := lic.Limit("max_users"); !ok || limit != 50 {
+		t.Fatalf("Limit(max_users) = %d, %v; want 50, true", limit, ok)
+	}
+	if _, ok := lic.Limit("tier"); ok {
+		t.Fatal("Limit(tier) found a string entitlement")
+	}
+	if value, ok := lic.Value("tier"); !ok || value != "pro" {
+		t.Fatalf("Value(tier) = %q, %v; want pro, true", value, ok)
+	}
+	if _, ok := lic.Value("max_users"); ok {
+		t.Fatal("Value(max_users) found a limit entitlement")
+	}
+
+	// 没有授权项的许可证
+	var empty Entitlements
+	if empty.HasFeature("export") {
+		t.Fatal("HasFeature on nil entitlements = true")
+	}
+	if _, ok := empty.Limit("max_users"); ok {
+		t.Fatal("Limit on nil entitlements found a value")
+	}
+}
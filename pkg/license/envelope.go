@@ -0,0 +1,310 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// 许可证容器（信封）格式（所有整数均为大端序）：
+//
+//	magic    4字节  "LMLK"
+//	version  1字节  格式版本（见 EnvelopeVersion）
+//	sigAlg   1字节  签名算法（见 SignatureAlgorithm）
+//	encAlg   1字节  加密算法（见 EncryptionAlgorithm）
+//	keyIDLen 1字节  密钥ID长度
+//	keyID    n字节  密钥ID（可为空）
+//	encIDLen 1字节  加密密钥ID长度
+//	encID    n字节  加密密钥ID（产品ID或设备公钥ID，可为空）
+//	length   4字节  载荷长度
+//	payload  n字节  载荷（加密后的许可证JSON，encAlg 为 0 时为明文JSON）
+//	signature       剩余字节，对以上全部内容的签名
+//
+// 载荷加密时将 magic 到 encID 的头部字段作为 AES-GCM 附加数据（见 AssociatedData），
+// 头部字段无法在许可证之间替换。
+
+// EnvelopeVersion 许可证容器的格式版本（解析时拒绝其他版本）
+const EnvelopeVersion byte = 1
+
+// envelopeMagic 信封魔数
+var envelopeMagic = []byte("LMLK")
+
+// SignatureAlgorithm 签名算法标识
+type SignatureAlgorithm byte
+
+const (
+	// SignatureRSAPSSSHA256 RSA-PSS + SHA-256
+	SignatureRSAPSSSHA256 SignatureAlgorithm = 1
+
+	// SignatureEd25519 Ed25519
+	SignatureEd25519 SignatureAlgorithm = 2
+)
+
+// algorithm 返回信封算法标识对应的签名算法（未知标识返回空字符串）
+func (a SignatureAlgorithm) algorithm() crypto.Algorithm {
+	switch a {
+	case SignatureRSAPSSSHA256:
+		return crypto.AlgorithmRSA
+	case SignatureEd25519:
+		return crypto.AlgorithmEd25519
+	default:
+		return ""
+	}
+}
+
+// EncryptionAlgorithm 载荷加密算法标识
+type EncryptionAlgorithm byte
+
+const (
+	// EncryptionNone 不加密，载荷为签名的明文JSON（仅需公钥即可验证和查看）
+	EncryptionNone EncryptionAlgorithm = 0
+
+	// EncryptionAES256GCM AES-256-GCM
+	EncryptionAES256GCM EncryptionAlgorithm = 1
+
+	// EncryptionX25519AES256GCM 加密到设备的X25519公钥（临时密钥协商 + HKDF + AES-256-GCM），
+	// 加密密钥ID为设备公钥的密钥ID
+	EncryptionX25519AES256GCM EncryptionAlgorithm = 2
+)
+
+// Envelope 许可证容器
+type Envelope struct {
+	Version             byte                // 格式版本
+	SignatureAlgorithm  SignatureAlgorithm  // 签名算法
+	EncryptionAlgorithm EncryptionAlgorithm // 加密算法
+	KeyID               string              // 签名密钥ID（可为空）
+	EncryptionKeyID     string              // 加密密钥ID（产品ID或设备公钥ID，为空表示使用全局AES密钥）
+	Payload             []byte              // 载荷
+	Signature           []byte              // 签名
+}
+
+// NewEnvelope 创建许可证容器（尚未签名），载荷加密算法为 AES-256-GCM
+// 仅签名不加密的许可证创建后将 EncryptionAlgorithm 设置为 EncryptionNone
+// 参数：
+//   - keyID: 签名密钥ID（可为空）
+//   - signatureAlgorithm: 签名算法
+//   - payload: 加密后的载荷（头部字段确定后使用 AssociatedData 加密的，可先传 nil 再设置 Payload）
+//
+// 返回值：
+//   - *Envelope: 许可证容器
+func NewEnvelope(keyID string, signatureAlgorithm SignatureAlgorithm, payload []byte) *Envelope {
+	return &Envelope{
+		Version:             EnvelopeVersion,
+		SignatureAlgorithm:  signatureAlgorithm,
+		EncryptionAlgorithm: EncryptionAES256GCM,
+		KeyID:               keyID,
+		Payload:             payload,
+	}
+}
+
+// AssociatedData 返回载荷加密时认证的附加数据（头部中载荷长度之前的全部字段，magic 到加密密钥ID）
+// 返回值：
+//   - []byte: 附加数据
+//   - error: 头部无效时的错误
+func (e *Envelope) AssociatedData() ([]byte, error) {
+	return e.headerFields()
+}
+
+// header 序列化头部（magic 到载荷长度）
+func (e *Envelope) header() ([]byte, error) {
+	fields, err := e.headerFields()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(fields)
+	binary.Write(&buf, binary.BigEndian, uint32(len(e.Payload)))
+	return buf.Bytes(), nil
+}
+
+// headerFields 序列化头部字段（magic 到加密密钥ID，不含载荷长度）
+func (e *Envelope) headerFields() ([]byte, error) {
+	if len(e.KeyID) > 255 {
+		return nil, fmt.Errorf("key ID too long: %d bytes", len(e.KeyID))
+	}
+	if len(e.EncryptionKeyID) > 255 {
+		return nil, fmt.Errorf("encryption key ID too long: %d bytes", len(e.EncryptionKeyID))
+	}
+
+	var buf bytes.Buffer
+	buf.Write(envelopeMagic)
+	buf.WriteByte(e.Version)
+	buf.WriteByte(byte(e.SignatureAlgorithm))
+	buf.WriteByte(byte(e.EncryptionAlgorithm))
+	buf.WriteByte(byte(len(e.KeyID)))
+	buf.WriteString(e.KeyID)
+	buf.WriteByte(byte(len(e.EncryptionKeyID)))
+	buf.WriteString(e.EncryptionKeyID)
+	return buf.Bytes(), nil
+}
+
+// SignedData 返回需要签名的数据（头部和载荷）
+// 返回值：
+//   - []byte: 待签名数据
+//   - error: 头部无效时的错误
+func (e *Envelope) SignedData() ([]byte, error) {
+	header, err := e.header()
+	if err != nil {
+		return nil, err
+	}
+	return append(header, e.Payload...), nil
+}
+
+// Marshal 序列化许可证容器
+// 返回值：
+//   - []byte: 二进制许可证数据（base64编码前）
+//   - error: 序列化过程中的错误
+func (e *Envelope) Marshal() ([]byte, error) {
+	signedData, err := e.SignedData()
+	if err != nil {
+		return nil, err
+	}
+	return append(signedData, e.Signature...), nil
+}
+
+// ParseEnvelope 解析许可证容器
+// 参数：
+//   - data: 二进制许可证数据（base64解码后）
+//
+// 返回值：
+//   - *Envelope: 许可证容器
+//   - error: 格式错误时返回 ErrInvalidLicense，版本不是 EnvelopeVersion 时返回 ErrUnsupportedVersion
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if !bytes.HasPrefix(data, envelopeMagic) || len(data) < len(envelopeMagic)+4 {
+		return nil, ErrInvalidLicense
+	}
+
+	version := data[4]
+	if version != EnvelopeVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	env := &Envelope{
+		Version:             version,
+		SignatureAlgorithm:  SignatureAlgorithm(data[5]),
+		EncryptionAlgorithm: EncryptionAlgorithm(data[6]),
+	}
+
+	offset := 8
+	keyIDLen := int(data[7])
+	if len(data) < offset+keyIDLen+4 {
+		return nil, ErrInvalidLicense
+	}
+	env.KeyID = string(data[offset : offset+keyIDLen])
+	offset += keyIDLen
+
+	encKeyIDLen := int(data[offset])
+	offset++
+	if len(data) < offset+encKeyIDLen+4 {
+		return nil, ErrInvalidLicense
+	}
+	env.EncryptionKeyID = string(data[offset : offset+encKeyIDLen])
+	offset += encKeyIDLen
+
+	payloadLen := int(binary.BigEndian.Uint32(data[offset:]))
+	offset += 4
+	if payloadLen > len(data)-offset {
+		return nil, ErrInvalidLicense
+	}
+	env.Payload = data[offset : offset+payloadLen]
+	env.Signature = data[offset+payloadLen:]
+	if len(env.Signature) == 0 {
+		return nil, ErrInvalidLicense
+	}
+
+	return env, nil
+}
+
+// Open 验证签名并使用AES密钥解密载荷
+// 参数：
+//   - keys: 受信任的公钥集合（按头部中的密钥ID选择公钥）
+//   - aesKey: AES密钥（32字节，与 EncryptionKeyID 对应；仅签名的许可证不需要，可为 nil）
+//
+// 返回值：
+//   - []byte: 解密后的许可证JSON
+//   - error: 见 OpenWith
+func (e *Envelope) Open(keys *KeySet, aesKey []byte) ([]byte, error) {
+	return e.OpenWith(keys, aesKey, nil)
+}
+
+// VerifySignature 只验证签名，不解密载荷
+// 用于无法解密载荷的一方（例如服务器验证加密到设备公钥的许可证）确认许可证由受信任的密钥签发且未被修改
+// 参数：
+//   - keys: 受信任的公钥集合（按头部中的密钥ID选择公钥）
+//
+// 返回值：
+//   - error: 算法不支持时返回 ErrUnsupportedAlgorithm，密钥ID未知时返回 ErrUnknownKey，签名无效时返回 ErrInvalidLicense
+func (e *Envelope) VerifySignature(keys *KeySet) error {
+	algorithm := e.SignatureAlgorithm.algorithm()
+	if algorithm == "" {
+		return fmt.Errorf("%w: signature algorithm %d", ErrUnsupportedAlgorithm, e.SignatureAlgorithm)
+	}
+
+	signedData, err := e.SignedData()
+	if err != nil {
+		return ErrInvalidLicense
+	}
+
+	return keys.Verify(e.KeyID, algorithm, signedData, e.Signature)
+}
+
+// OpenWith 验证签名并解密载荷，根据加密算法使用AES密钥或设备私钥
+// 参数：
+//   - keys: 受信任的公钥集合（按头部中的密钥ID选择公钥）
+//   - aesKey: AES密钥（32字节，可为 nil）
+//   - devicePrivateKey: 设备X25519私钥（32字节，可为 nil）
+//
+// 返回值：
+//   - []byte: 解密后的许可证JSON
+//   - error: 算法不支持时返回 ErrUnsupportedAlgorithm，密钥ID未知时返回 ErrUnknownKey，
+//     许可证已加密但未提供AES密钥时返回 ErrEncryptedLicense，加密到设备密钥但未提供设备私钥时返回 ErrDeviceKeyRequired，
+//     签名无效或解密失败时返回 ErrInvalidLicense
+func (e *Envelope) OpenWith(keys *KeySet, aesKey, devicePrivateKey []byte) ([]byte, error) {
+	algorithm := e.SignatureAlgorithm.algorithm()
+	if algorithm == "" {
+		return nil, fmt.Errorf("%w: signature algorithm %d", ErrUnsupportedAlgorithm, e.SignatureAlgorithm)
+	}
+	switch e.EncryptionAlgorithm {
+	case EncryptionNone:
+	case EncryptionAES256GCM:
+		if aesKey == nil {
+			return nil, ErrEncryptedLicense
+		}
+	case EncryptionX25519AES256GCM:
+		if devicePrivateKey == nil {
+			return nil, ErrDeviceKeyRequired
+		}
+	default:
+		return nil, fmt.Errorf("%w: encryption algorithm %d", ErrUnsupportedAlgorithm, e.EncryptionAlgorithm)
+	}
+
+	if err := e.VerifySignature(keys); err != nil {
+		return nil, err
+	}
+
+	// 头部作为附加数据参与认证
+	aad, err := e.AssociatedData()
+	if err != nil {
+		return nil, ErrInvalidLicense
+	}
+
+	var plaintext []byte
+	switch e.EncryptionAlgorithm {
+	case EncryptionNone:
+		// 仅签名的许可证载荷即为许可证JSON
+		return e.Payload, nil
+	case EncryptionX25519AES256GCM:
+		plaintext, err = crypto.DecryptX25519(e.Payload, devicePrivateKey, aad)
+	default:
+		plaintext, err = crypto.DecryptAESWithAAD(e.Payload, aesKey, aad)
+	}
+	if err != nil {
+		return nil, ErrInvalidLicense
+	}
+
+	return plaintext, nil
+}
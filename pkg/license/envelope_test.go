@@ -0,0 +1,280 @@
+package license
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// testPayload 测试使用的许可证JSON
+var testPayload = []byte(`{"id":"lic-1","device_id":"device123"}`)
+
+// newTestSigner 生成Ed25519签名器
+func newTestSigner(t *testing.T) crypto.Signer {
+	t.Helper()
+	privateKey, _, err := crypto.GenerateEd25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateEd25519KeyPair: %v", err)
+	}
+	return crypto.NewEd25519Signer(privateKey)
+}
+
+// newTestKeySet 创建包含指定签名器公钥的公钥集合
+func newTestKeySet(t *testing.T, signers ...crypto.Signer) *KeySet {
+	t.Helper()
+	var publicKeyPEM []byte
+	for _, signer := range signers {
+		data, err := crypto.EncodePublicKeyPEM(signer.Public())
+		if err != nil {
+			t.Fatalf("EncodePublicKeyPEM: %v", err)
+		}
+		publicKeyPEM = append(publicKeyPEM, data...)
+	}
+	keys, err := NewKeySet(publicKeyPEM)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return keys
+}
+
+// testKeyID 计算签名器的密钥ID
+func testKeyID(t *testing.T, signer crypto.Signer) string {
+	t.Helper()
+	id, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		t.Fatalf("KeyID: %v", err)
+	}
+	return id
+}
+
+// resign 使用签名器重新签名许可证容器
+func resign(t *testing.T, signer crypto.Signer, env *Envelope) {
+	t.Helper()
+	signedData, err := env.SignedData()
+	if err != nil {
+		t.Fatalf("SignedData: %v", err)
+	}
+	env.Signature, err = signer.Sign(signedData)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+}
+
+// sealTestEnvelope 按生成器的方式创建已签名的许可证容器
+// encryptionKey 为 EncryptionAES256GCM 的AES密钥或 EncryptionX25519AES256GCM 的设备公钥
+func sealTestEnvelope(t *testing.T, signer crypto.Signer, encryption EncryptionAlgorithm, encryptionKeyID string, encryptionKey []byte) *Envelope {
+	t.Helper()
+	env := NewEnvelope(testKeyID(t, signer), SignatureEd25519, nil)
+	env.EncryptionAlgorithm = encryption
+	env.EncryptionKeyID = encryptionKeyID
+
+	aad, err := env.AssociatedData()
+	if err != nil {
+		t.Fatalf("AssociatedData: %v", err)
+	}
+	switch encryption {
+	case EncryptionAES256GCM:
+		env.Payload, err = crypto.EncryptAESWithAAD(testPayload, encryptionKey, aad)
+	case EncryptionX25519AES256GCM:
+		env.Payload, err = crypto.EncryptX25519(testPayload, encryptionKey, aad)
+	default:
+		env.Payload = testPayload
+	}
+	if err != nil {
+		t.Fatalf("encrypt payload: %v", err)
+	}
+
+	resign(t, signer, env)
+	return env
+}
+
+// reparse 序列化后重新解析许可证容器
+func reparse(t *testing.T, env *Envelope) *Envelope {
+	t.Helper()
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	parsed, err := ParseEnvelope(data)
+	if err != nil {
+		t.Fatalf("ParseEnvelope: %v", err)
+	}
+	return parsed
+}
+
+func TestEnvelopeHeaderTampering(t *testing.T) {
+	signerA := newTestSigner(t)
+	signerB := newTestSigner(t)
+	// 两个受信任的签名密钥（模拟密钥轮换后的公钥集合）
+	keys := newTestKeySet(t, signerA, signerB)
+
+	aesKey := bytes.Repeat([]byte{0x42}, 32)
+	devicePrivateKey, devicePublicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+
+	paths := []struct {
+		name            string
+		encryption      EncryptionAlgorithm
+		encryptionKeyID string
+		encryptionKey   []byte
+		otherEncryption EncryptionAlgorithm
+	}{
+		{"aes", EncryptionAES256GCM, "", aesKey, EncryptionX25519AES256GCM},
+		{"x25519", EncryptionX25519AES256GCM, crypto.X25519KeyID(devicePublicKey), devicePublicKey, EncryptionAES256GCM},
+	}
+
+	tampers := []struct {
+		name   string
+		signer crypto.Signer // 篡改后重新签名使用的受信任密钥
+		tamper func(env *Envelope, otherEncryption EncryptionAlgorithm)
+	}{
+		{"swapped key ID", signerB, func(env *Envelope, _ EncryptionAlgorithm) {
+			env.KeyID = testKeyID(t, signerB)
+		}},
+		{"changed encryption algorithm", signerA, func(env *Envelope, other EncryptionAlgorithm) {
+			env.EncryptionAlgorithm = other
+		}},
+		{"swapped encryption key ID", signerA, func(env *Envelope, _ EncryptionAlgorithm) {
+			env.EncryptionKeyID = "0000000000000000"
+		}},
+	}
+
+	for _, path := range paths {
+		t.Run(path.name, func(t *testing.T) {
+			env := sealTestEnvelope(t, signerA, path.encryption, path.encryptionKeyID, path.encryptionKey)
+			if env.Version != EnvelopeVersion {
+				t.Fatalf("Version = %d, want %d", env.Version, EnvelopeVersion)
+			}
+			plaintext, err := reparse(t, env).OpenWith(keys, aesKey, devicePrivateKey)
+			if err != nil {
+				t.Fatalf("OpenWith untampered: %v", err)
+			}
+			if !bytes.Equal(plaintext, testPayload) {
+				t.Fatalf("OpenWith = %q, want %q", plaintext, testPayload)
+			}
+
+			for _, tc := range tampers {
+				t.Run(tc.name, func(t *testing.T) {
+					// 只修改头部：签名校验失败
+					tampered := reparse(t, env)
+					tc.tamper(tampered, path.otherEncryption)
+					if _, err := reparse(t, tampered).OpenWith(keys, aesKey, devicePrivateKey); !errors.Is(err, ErrInvalidLicense) {
+						t.Fatalf("OpenWith unsigned tamper: err = %v, want ErrInvalidLicense", err)
+					}
+
+					// 使用受信任的密钥重新签名：签名有效，但头部与密文的附加数据不匹配，解密失败
+					resign(t, tc.signer, tampered)
+					signedData, err := tampered.SignedData()
+					if err != nil {
+						t.Fatalf("SignedData: %v", err)
+					}
+					if err := keys.Verify(tampered.KeyID, tampered.SignatureAlgorithm.algorithm(), signedData, tampered.Signature); err != nil {
+						t.Fatalf("Verify after resign: %v", err)
+					}
+					if _, err := reparse(t, tampered).OpenWith(keys, aesKey, devicePrivateKey); !errors.Is(err, ErrInvalidLicense) {
+						t.Fatalf("OpenWith resigned tamper: err = %v, want ErrInvalidLicense", err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestEnvelopeMissingKeys(t *testing.T) {
+	signer := newTestSigner(t)
+	keys := newTestKeySet(t, signer)
+	aesKey := bytes.Repeat([]byte{0x42}, 32)
+	devicePrivateKey, devicePublicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		env  *Envelope
+		want error
+	}{
+		{"aes without aes key", sealTestEnvelope(t, signer, EncryptionAES256GCM, "", aesKey), ErrEncryptedLicense},
+		{"x25519 without device key", sealTestEnvelope(t, signer, EncryptionX25519AES256GCM, crypto.X25519KeyID(devicePublicKey), devicePublicKey), ErrDeviceKeyRequired},
+		{"unknown signer", sealTestEnvelope(t, newTestSigner(t), EncryptionNone, "", nil), ErrUnknownKey},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.env.OpenWith(keys, nil, nil); !errors.Is(err, tc.want) {
+				t.Fatalf("OpenWith: err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	// 仅签名的许可证只需要公钥
+	plaintext, err := sealTestEnvelope(t, signer, EncryptionNone, "", nil).OpenWith(keys, nil, nil)
+	if err != nil {
+		t.Fatalf("OpenWith sign-only: %v", err)
+	}
+	if !bytes.Equal(plaintext, testPayload) {
+		t.Fatalf("OpenWith sign-only = %q, want %q", plaintext, testPayload)
+	}
+
+	// 加密到其他设备的许可证无法解密
+	otherPrivateKey, _, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+	env := sealTestEnvelope(t, signer, EncryptionX25519AES256GCM, crypto.X25519KeyID(devicePublicKey), devicePublicKey)
+	if _, err := env.OpenWith(keys, nil, otherPrivateKey); !errors.Is(err, ErrInvalidLicense) {
+		t.Fatalf("OpenWith other device key: err = %v, want ErrInvalidLicense", err)
+	}
+	if _, err := env.OpenWith(keys, nil, devicePrivateKey); err != nil {
+		t.Fatalf("OpenWith device key: %v", err)
+	}
+}
+
+func TestParseEnvelope(t *testing.T) {
+	signer := newTestSigner(t)
+	env := sealTestEnvelope(t, signer, EncryptionAES256GCM, "acme", bytes.Repeat([]byte{0x42}, 32))
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	parsed, err := ParseEnvelope(data)
+	if err != nil {
+		t.Fatalf("ParseEnvelope: %v", err)
+	}
+	if parsed.Version != env.Version || parsed.SignatureAlgorithm != env.SignatureAlgorithm ||
+		parsed.EncryptionAlgorithm != env.EncryptionAlgorithm || parsed.KeyID != env.KeyID ||
+		parsed.EncryptionKeyID != env.EncryptionKeyID || !bytes.Equal(parsed.Payload, env.Payload) ||
+		!bytes.Equal(parsed.Signature, env.Signature) {
+		t.Fatalf("ParseEnvelope = %+v, want %+v", parsed, env)
+	}
+
+	noMagic := bytes.Clone(data)
+	noMagic[0] = 0
+	unsupported := bytes.Clone(data)
+	unsupported[4] = EnvelopeVersion + 1
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidLicense},
+		{"no magic", noMagic, ErrInvalidLicense},
+		{"magic only", []byte("LMLK"), ErrInvalidLicense},
+		{"truncated header", data[:10], ErrInvalidLicense},
+		{"truncated payload", data[:len(data)-len(env.Signature)-1], ErrInvalidLicense},
+		{"missing signature", data[:len(data)-len(env.Signature)], ErrInvalidLicense},
+		{"unsupported version", unsupported, ErrUnsupportedVersion},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseEnvelope(tc.data); !errors.Is(err, tc.want) {
+				t.Fatalf("ParseEnvelope: err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
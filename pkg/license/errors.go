@@ -11,15 +11,87 @@ var (
 	// ErrExpiredLicense 表示许可证已过期
 	ErrExpiredLicense = errors.New("license expired")
 
+	// ErrRevokedLicense 表示许可证已被撤销
+	ErrRevokedLicense = errors.New("license revoked")
+
+	// ErrLicenseNotYetValid 表示许可证尚未到生效时间（NotBefore）
+	ErrLicenseNotYetValid = errors.New("license not yet valid")
+
+	// ErrInvalidCRL 表示吊销列表格式错误或签名无效
+	ErrInvalidCRL = errors.New("invalid revocation list")
+
+	// ErrCRLExpired 表示吊销列表已超过下次更新时间（严格模式）
+	ErrCRLExpired = errors.New("revocation list expired")
+
 	// ErrDeviceMismatch 表示设备ID不匹配
 	ErrDeviceMismatch = errors.New("device ID mismatch")
 
+	// ErrProductMismatch 表示许可证签发给其他产品，或不适用于当前产品版本
+	ErrProductMismatch = errors.New("license not valid for this product or version")
+
 	// ErrNetworkError 表示网络验证失败
 	ErrNetworkError = errors.New("network verification failed")
 
+	// ErrUnauthorized 表示API Token无效或无权访问
+	ErrUnauthorized = errors.New("unauthorized: invalid or missing API token")
+
+	// ErrNoSeatsAvailable 表示浮动许可证的席位已全部被占用
+	ErrNoSeatsAvailable = errors.New("no floating license seats available")
+
+	// ErrLeaseLost 表示浮动许可证的席位租约已失效（心跳超时后席位被服务器回收）
+	ErrLeaseLost = errors.New("floating license seat lease lost")
+
+	// ErrActivationLimit 表示多设备许可证的激活设备数已达上限
+	ErrActivationLimit = errors.New("license activation limit reached")
+
+	// ErrNotActivated 表示设备未激活该多设备许可证
+	ErrNotActivated = errors.New("device not activated for license")
+
+	// ErrTrialUsed 表示该设备已经使用过试用许可证（每台设备只能试用一次）
+	ErrTrialUsed = errors.New("trial already used on this device")
+
+	// ErrStateRequired 表示离线验证试用许可证需要客户端状态目录（见 SetStateDir）
+	ErrStateRequired = errors.New("license state directory is required")
+
+	// ErrStateTampered 表示客户端状态文件被修改或来自其他设备
+	ErrStateTampered = errors.New("license state file has been tampered with")
+
+	// ErrStateKeyRequired 表示验证器没有AES密钥或设备私钥，无法保护客户端状态文件（只持有公钥的验证器不能使用状态文件）
+	ErrStateKeyRequired = errors.New("license state requires an AES key or device key")
+
+	// ErrClockTampered 表示系统时间比上次验证成功时回退超过容差（时钟回拨）
+	ErrClockTampered = errors.New("system clock has been set back")
+
+	// ErrInvalidRenewalRequest 表示离线续期请求格式错误
+	ErrInvalidRenewalRequest = errors.New("invalid renewal request")
+
+	// ErrRenewalMismatch 表示续期响应与本设备待处理的续期请求不一致（或没有待处理的请求）
+	ErrRenewalMismatch = errors.New("renewal response does not match the pending request")
+
 	// ErrLicenseNotFound 表示未找到许可证
 	ErrLicenseNotFound = errors.New("license not found")
 
+	// ErrUnsupportedVersion 表示许可证格式版本不受支持（需要升级客户端）
+	ErrUnsupportedVersion = errors.New("unsupported license format version")
+
+	// ErrUnsupportedAlgorithm 表示许可证使用了不受支持的签名或加密算法
+	ErrUnsupportedAlgorithm = errors.New("unsupported license algorithm")
+
+	// ErrUnknownKey 表示许可证由不受信任的密钥签名（密钥ID不在公钥集合中）
+	ErrUnknownKey = errors.New("license signed by unknown key")
+
+	// ErrEncryptionKeyMismatch 表示许可证使用其他产品（或其他设备）的密钥加密
+	ErrEncryptionKeyMismatch = errors.New("license encrypted for a different product or device")
+
+	// ErrEncryptedLicense 表示许可证已加密，但验证器只有公钥（见 NewPublicVerifier）
+	ErrEncryptedLicense = errors.New("license is encrypted and no AES key was provided")
+
+	// ErrDeviceKeyRequired 表示许可证加密到设备密钥，但验证器没有设备私钥（见 SetDeviceKey）
+	ErrDeviceKeyRequired = errors.New("license is encrypted to a device key and no device key was provided")
+
+	// ErrDeviceRegistered 表示设备已经注册（更换设备公钥需要管理员执行 device rekey）
+	ErrDeviceRegistered = errors.New("device already registered")
+
 	// ErrInvalidKey 表示密钥无效
 	ErrInvalidKey = errors.New("invalid key")
 )
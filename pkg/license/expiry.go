@@ -0,0 +1,60 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ExpiryPolicy 到期策略
+// 到期前的提醒期内验证成功并设置 ExpiresSoon；到期后的宽限期内验证仍然成功并设置 InGracePeriod，
+// 便于应用提醒用户续期而不是直接停止服务。零值表示没有提醒期和宽限期
+type ExpiryPolicy struct {
+	GracePeriod   time.Duration // 到期后的宽限期
+	WarningPeriod time.Duration // 到期前的提醒期
+}
+
+// Apply 根据到期时间设置验证结果的 Expired、InGracePeriod、ExpiresSoon 和 DaysRemaining
+// 不修改 Valid，调用方根据 Expired 和其他检查结果设置；永久许可证不过期，这些字段均为零值
+// 参数：
+//   - result: 验证结果（使用其中的 ExpiryDate 和 Perpetual）
+//   - now: 当前时间
+func (p ExpiryPolicy) Apply(result *VerifyResult, now time.Time) {
+	if result.Perpetual {
+		result.Expired, result.InGracePeriod, result.ExpiresSoon, result.DaysRemaining = false, false, false, 0
+		return
+	}
+
+	remaining := result.ExpiryDate.Sub(now)
+
+	result.DaysRemaining = int(math.Floor(remaining.Hours() / 24))
+	result.Expired = remaining < -p.GracePeriod
+	result.InGracePeriod = remaining < 0 && !result.Expired
+	result.ExpiresSoon = p.WarningPeriod > 0 && remaining >= 0 && remaining <= p.WarningPeriod
+}
+
+// Validate 校验到期策略
+// 返回值：
+//   - error: 宽限期或提醒期为负数时的错误
+func (p ExpiryPolicy) Validate() error {
+	if p.GracePeriod < 0 || p.WarningPeriod < 0 {
+		return fmt.Errorf("grace period and warning period must not be negative")
+	}
+	return nil
+}
+
+// SetExpiryPolicy 设置离线验证的到期策略（宽限期和提醒期）
+// 参数：
+//   - policy: 到期策略
+//
+// 返回值：
+//   - error: 策略无效时的错误
+func (v *OfflineVerifier) SetExpiryPolicy(policy ExpiryPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	v.expiryPolicy = policy
+	return nil
+}
@@ -0,0 +1,67 @@
+package license
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryPolicyApply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	policy := ExpiryPolicy{GracePeriod: 7 * day, WarningPeriod: 30 * day}
+
+	tests := []struct {
+		name          string
+		policy        ExpiryPolicy
+		expiry        time.Duration // 到期时间相对 now 的偏移
+		perpetual     bool
+		wantExpired   bool
+		wantGrace     bool
+		wantSoon      bool
+		wantRemaining int
+	}{
+		{"far from expiry", policy, 90 * day, false, false, false, false, 90},
+		{"warning period starts", policy, 30 * day, false, false, false, true, 30},
+		{"inside warning period", policy, 10*day + time.Hour, false, false, false, true, 10},
+		{"less than a day left", policy, time.Hour, false, false, false, true, 0},
+		{"exactly at expiry", policy, 0, false, false, false, true, 0},
+		{"just expired", policy, -time.Hour, false, false, true, false, -1},
+		{"grace period ends", policy, -7 * day, false, false, true, false, -7},
+		{"after grace period", policy, -7*day - time.Second, false, true, false, false, -8},
+		{"no policy before expiry", ExpiryPolicy{}, day, false, false, false, false, 1},
+		{"no policy after expiry", ExpiryPolicy{}, -time.Second, false, true, false, false, -1},
+		{"perpetual", policy, -365 * day, true, false, false, false, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := &VerifyResult{ExpiryDate: now.Add(tc.expiry), Perpetual: tc.perpetual, Valid: true}
+			tc.policy.Apply(result, now)
+			if result.Expired != tc.wantExpired || result.InGracePeriod != tc.wantGrace ||
+				result.ExpiresSoon != tc.wantSoon || result.DaysRemaining != tc.wantRemaining {
+				t.Fatalf("Apply = expired %v, grace %v, soon %v, remaining %d; want %v, %v, %v, %d",
+					result.Expired, result.InGracePeriod, result.ExpiresSoon, result.DaysRemaining,
+					tc.wantExpired, tc.wantGrace, tc.wantSoon, tc.wantRemaining)
+			}
+			if !result.Valid {
+				t.Fatal("Apply modified Valid")
+			}
+		})
+	}
+}
+
+func TestExpiryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy  ExpiryPolicy
+		wantErr bool
+	}{
+		{ExpiryPolicy{}, false},
+		{ExpiryPolicy{GracePeriod: time.Hour, WarningPeriod: time.Hour}, false},
+		{ExpiryPolicy{GracePeriod: -time.Hour}, true},
+		{ExpiryPolicy{WarningPeriod: -time.Hour}, true},
+	}
+	for _, tc := range tests {
+		if err := tc.policy.Validate(); (err != nil) != tc.wantErr {
+			t.Fatalf("Validate(%+v): err = %v, want error %v", tc.policy, err, tc.wantErr)
+		}
+	}
+}
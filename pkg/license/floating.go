@@ -0,0 +1,207 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FloatingClient 浮动许可证客户端
+// 从授权服务器签出席位，席位在后台定期续期，Close 时签入
+type FloatingClient struct {
+	config *OnlineConfig
+	client *http.Client
+}
+
+// NewFloatingClient 创建浮动许可证客户端
+// 参数：
+//   - config: 网络验证配置（APIURL、AppID、Token 与网络验证相同）
+//
+// 返回值：
+//   - *FloatingClient: 浮动许可证客户端实例
+func NewFloatingClient(config *OnlineConfig) *FloatingClient {
+	if config.Timeout == 0 {
+		config.Timeout = 10
+	}
+
+	return &FloatingClient{
+		config: config,
+		client: &http.Client{Timeout: time.Duration(config.Timeout) * time.Second},
+	}
+}
+
+// leaseResponse 席位签出和续期的响应
+type leaseResponse struct {
+	LeaseID           string    `json:"lease_id"`
+	LicenseID         string    `json:"license_id"`
+	DeviceID          string    `json:"device_id"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	HeartbeatInterval int       `json:"heartbeat_interval"` // 建议的心跳间隔（秒）
+	Seats             int       `json:"seats"`
+	SeatsInUse        int       `json:"seats_in_use"`
+}
+
+// Checkout 签出浮动许可证的席位，并在后台定期发送心跳续期
+// 使用完毕后必须调用 Seat.Close 释放席位；进程崩溃时席位在租约到期后由服务器回收
+// 参数：
+//   - licenseID: 许可证ID
+//   - deviceID: 当前设备ID
+//
+// 返回值：
+//   - *Seat: 签出的席位
+//   - error: 席位已满时返回 ErrNoSeatsAvailable，许可证已撤销或过期时返回 ErrRevokedLicense、ErrExpiredLicense
+func (c *FloatingClient) Checkout(licenseID, deviceID string) (*Seat, error) {
+	var resp leaseResponse
+	err := c.post("/license/checkout", map[string]string{
+		"license_id": licenseID,
+		"device_id":  deviceID,
+		"app_id":     c.config.AppID,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(resp.HeartbeatInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	seat := &Seat{
+		LeaseID:    resp.LeaseID,
+		LicenseID:  resp.LicenseID,
+		DeviceID:   resp.DeviceID,
+		Seats:      resp.Seats,
+		SeatsInUse: resp.SeatsInUse,
+		client:     c,
+		interval:   interval,
+		expiresAt:  resp.ExpiresAt,
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+	}
+	seat.wg.Add(1)
+	go seat.renew()
+
+	return seat, nil
+}
+
+// post 发送POST请求并解析响应，见 postJSON
+func (c *FloatingClient) post(path string, reqBody interface{}, out interface{}) error {
+	return postJSON(c.client, c.config, path, reqBody, out)
+}
+
+// Seat 签出的浮动许可证席位
+type Seat struct {
+	LeaseID    string // 租约ID
+	LicenseID  string // 许可证ID
+	DeviceID   string // 设备ID
+	Seats      int    // 许可证的席位数
+	SeatsInUse int    // 签出时占用的席位数
+
+	client   *FloatingClient
+	interval time.Duration // 心跳间隔
+
+	mu        sync.Mutex
+	expiresAt time.Time // 租约到期时间
+	err       error     // 席位失效的原因
+
+	done      chan struct{} // 席位失效或关闭时关闭
+	stop      chan struct{} // 通知后台续期退出
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// ExpiresAt 返回租约的到期时间（每次心跳成功后延长）
+func (s *Seat) ExpiresAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expiresAt
+}
+
+// Done 返回在席位失效或 Close 后关闭的通道
+// 通道关闭后调用 Err 获取席位失效的原因，应用应停止使用受许可证保护的功能
+func (s *Seat) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err 返回席位失效的原因
+// 返回值：
+//   - error: 租约被回收时为 ErrLeaseLost，许可证被撤销或过期时为 ErrRevokedLicense、ErrExpiredLicense；席位有效或正常关闭时为 nil
+func (s *Seat) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close 停止后台续期并签入席位，可重复调用
+// 返回值：
+//   - error: 签入过程中的错误（租约已失效时忽略）
+func (s *Seat) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+
+		if s.Err() != nil {
+			return
+		}
+		close(s.done)
+
+		var resp map[string]interface{}
+		err := s.client.post("/license/checkin", map[string]string{
+			"lease_id": s.LeaseID,
+			"app_id":   s.client.config.AppID,
+		}, &resp)
+		if err != nil && !errors.Is(err, ErrLeaseLost) {
+			s.closeErr = err
+		}
+	})
+	return s.closeErr
+}
+
+// renew 按心跳间隔续期租约，直到关闭或席位失效
+// 网络错误时继续重试，超过租约到期时间仍未续期成功则视为租约丢失
+func (s *Seat) renew() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		var resp leaseResponse
+		err := s.client.post("/license/heartbeat", map[string]string{
+			"lease_id": s.LeaseID,
+			"app_id":   s.client.config.AppID,
+		}, &resp)
+		switch {
+		case err == nil:
+			s.mu.Lock()
+			s.expiresAt = resp.ExpiresAt
+			s.mu.Unlock()
+		case errors.Is(err, ErrNetworkError):
+			if time.Now().After(s.ExpiresAt()) {
+				s.fail(fmt.Errorf("%w: %v", ErrLeaseLost, err))
+				return
+			}
+		default:
+			s.fail(err)
+			return
+		}
+	}
+}
+
+// fail 记录席位失效的原因并关闭 Done 通道
+func (s *Seat) fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	close(s.done)
+}
@@ -0,0 +1,94 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"fmt"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// KeySet 受信任的签名公钥集合
+// 密钥轮换后，新许可证使用新密钥签名，已签发的许可证仍由已退役的公钥验证
+type KeySet struct {
+	verifiers map[string]crypto.Verifier // 按密钥ID索引
+	keyIDs    []string                   // 添加顺序
+}
+
+// NewKeySet 创建公钥集合
+// 参数：
+//   - publicKeyPEM: PEM编码的公钥（可包含多个 PUBLIC KEY 块）
+//
+// 返回值：
+//   - *KeySet: 公钥集合
+//   - error: 公钥无效时的错误
+func NewKeySet(publicKeyPEM []byte) (*KeySet, error) {
+	keys := &KeySet{verifiers: make(map[string]crypto.Verifier)}
+	if err := keys.Add(publicKeyPEM); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Add 添加受信任的公钥
+// 参数：
+//   - publicKeyPEM: PEM编码的公钥（可包含多个 PUBLIC KEY 块）
+//
+// 返回值：
+//   - error: 公钥无效时的错误
+func (k *KeySet) Add(publicKeyPEM []byte) error {
+	verifiers, err := crypto.ParseVerifiers(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	for _, verifier := range verifiers {
+		keyID, err := crypto.KeyID(verifier.Public())
+		if err != nil {
+			return err
+		}
+		if _, ok := k.verifiers[keyID]; !ok {
+			k.keyIDs = append(k.keyIDs, keyID)
+		}
+		k.verifiers[keyID] = verifier
+	}
+	return nil
+}
+
+// KeyIDs 返回所有受信任公钥的密钥ID
+func (k *KeySet) KeyIDs() []string {
+	return append([]string(nil), k.keyIDs...)
+}
+
+// Verify 验证签名
+// 指定密钥ID时只使用对应公钥；密钥ID为空时（旧版许可证、吊销列表）依次尝试所有公钥
+// 参数：
+//   - keyID: 签名密钥ID（可为空）
+//   - algorithm: 签名算法（为空时不限制）
+//   - data: 原始数据
+//   - signature: 签名
+//
+// 返回值：
+//   - error: 密钥ID未知时返回 ErrUnknownKey，签名无效时返回 ErrInvalidLicense
+func (k *KeySet) Verify(keyID string, algorithm crypto.Algorithm, data []byte, signature []byte) error {
+	if keyID != "" {
+		verifier, ok := k.verifiers[keyID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+		}
+		if (algorithm != "" && verifier.Algorithm() != algorithm) || !verifier.Verify(data, signature) {
+			return ErrInvalidLicense
+		}
+		return nil
+	}
+
+	for _, id := range k.keyIDs {
+		verifier := k.verifiers[id]
+		if algorithm != "" && verifier.Algorithm() != algorithm {
+			continue
+		}
+		if verifier.Verify(data, signature) {
+			return nil
+		}
+	}
+	return ErrInvalidLicense
+}
@@ -2,7 +2,6 @@
 package license
 
 import (
-	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -16,21 +15,33 @@ import (
 // OfflineVerifier 离线验证器
 // 完全本地验证，不需要网络连接
 type OfflineVerifier struct {
-	publicKey *rsa.PublicKey // RSA公钥（用于验证签名）
-	aesKey    []byte         // AES密钥（用于解密）
+	keys            *KeySet       // 受信任的签名公钥（RSA或Ed25519，按密钥ID选择）
+	aesKey          []byte        // AES密钥（用于解密，仅签名模式为 nil）
+	encryptionKeyID string        // AES密钥对应的产品ID（为空表示全局AES密钥）
+	deviceKey       []byte        // 设备X25519私钥（可选，见 SetDeviceKey）
+	deviceKeyID     string        // 设备公钥的密钥ID
+	productID       string        // 期望的产品ID（可选，见 SetProduct）
+	productVersion  string        // 当前产品版本
+	crl             *CRL          // 吊销列表（可选，见 LoadCRL）
+	crlStrict       bool          // CRL过期后是否拒绝所有许可证
+	stateDir        string        // 客户端状态目录（可选，见 SetStateDir）
+	clockCheck      bool          // 是否检测时钟回拨（见 EnableClockCheck）
+	clockTolerance  time.Duration // 允许的时钟回退
+	expiryPolicy    ExpiryPolicy  // 到期策略（宽限期和提醒期，见 SetExpiryPolicy）
 }
 
 // NewOfflineVerifier 创建离线验证器
 // 参数：
-//   - publicKeyPEM: RSA公钥（PEM格式），用于验证许可证签名
+//   - publicKeyPEM: 公钥（PEM格式，RSA或Ed25519），用于验证许可证签名
+//     密钥轮换后可以包含多个 PUBLIC KEY 块（当前公钥 + 已退役公钥）
 //   - aesKey: AES密钥（32字节），用于解密许可证
 //
 // 返回值：
 //   - *OfflineVerifier: 离线验证器实例
 //   - error: 创建过程中的错误
 func NewOfflineVerifier(publicKeyPEM []byte, aesKey []byte) (*OfflineVerifier, error) {
-	// 解码公钥
-	publicKey, err := crypto.DecodePublicKey(publicKeyPEM)
+	// 解码公钥（根据公钥类型自动选择签名算法）
+	keys, err := NewKeySet(publicKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode public key: %w", err)
 	}
@@ -41,11 +52,107 @@ func NewOfflineVerifier(publicKeyPEM []byte, aesKey []byte) (*OfflineVerifier, e
 	}
 
 	return &OfflineVerifier{
-		publicKey: publicKey,
-		aesKey:    aesKey,
+		keys:   keys,
+		aesKey: aesKey,
 	}, nil
 }
 
+// NewPublicVerifier 创建只持有公钥的离线验证器
+// 只能验证仅签名（未加密）的许可证，客户端无需分发任何密钥；加密的许可证返回 ErrEncryptedLicense
+// 参数：
+//   - publicKeyPEM: 公钥（PEM格式，可包含多个 PUBLIC KEY 块）
+//
+// 返回值：
+//   - *OfflineVerifier: 离线验证器实例
+//   - error: 创建过程中的错误
+func NewPublicVerifier(publicKeyPEM []byte) (*OfflineVerifier, error) {
+	keys, err := NewKeySet(publicKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	return &OfflineVerifier{keys: keys}, nil
+}
+
+// NewProductVerifier 创建只验证单个产品许可证的离线验证器
+// 客户端只持有该产品的派生密钥，其他产品（或使用全局AES密钥）的许可证返回 ErrEncryptionKeyMismatch
+// 参数：
+//   - publicKeyPEM: 公钥（PEM格式，可包含多个 PUBLIC KEY 块）
+//   - productID: 产品或客户ID
+//   - productKey: 产品AES密钥（32字节，由 licensemanager key export-aes --product 导出）
+//
+// 返回值：
+//   - *OfflineVerifier: 离线验证器实例
+//   - error: 创建过程中的错误
+func NewProductVerifier(publicKeyPEM []byte, productID string, productKey []byte) (*OfflineVerifier, error) {
+	if productID == "" {
+		return nil, fmt.Errorf("product ID is required")
+	}
+
+	verifier, err := NewOfflineVerifier(publicKeyPEM, productKey)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier.encryptionKeyID = productID
+	return verifier, nil
+}
+
+// AddPublicKey 添加受信任的公钥（例如已退役但仍需验证旧许可证的公钥）
+// 参数：
+//   - publicKeyPEM: PEM编码的公钥（可包含多个 PUBLIC KEY 块）
+//
+// 返回值：
+//   - error: 公钥无效时的错误
+func (v *OfflineVerifier) AddPublicKey(publicKeyPEM []byte) error {
+	if err := v.keys.Add(publicKeyPEM); err != nil {
+		return fmt.Errorf("failed to decode public key: %w", err)
+	}
+	return nil
+}
+
+// SetProduct 设置验证器所在的产品和版本
+// 设置后只接受签发给该产品的许可证（未指定产品的许可证也会被拒绝），
+// 许可证限制了版本范围时当前版本必须在范围内，否则返回 ErrProductMismatch
+// 参数：
+//   - productID: 产品ID
+//   - version: 当前产品版本（例如 2.3.1；为空时无法验证限制了版本范围的许可证）
+//
+// 返回值：
+//   - error: 参数无效时的错误
+func (v *OfflineVerifier) SetProduct(productID, version string) error {
+	if productID == "" {
+		return fmt.Errorf("product ID is required")
+	}
+	if version != "" {
+		if _, err := parseVersion(version); err != nil {
+			return err
+		}
+	}
+
+	v.productID = productID
+	v.productVersion = version
+	return nil
+}
+
+// SetDeviceKey 设置设备私钥，用于解密加密到本设备公钥的许可证
+// 设备私钥由客户端首次运行时生成（见 device.LoadOrCreateKeyPair），公钥在设备注册时提交给服务器
+// 参数：
+//   - privateKey: 设备X25519私钥（32字节）
+//
+// 返回值：
+//   - error: 私钥无效时的错误
+func (v *OfflineVerifier) SetDeviceKey(privateKey []byte) error {
+	publicKey, err := crypto.X25519PublicKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("invalid device key: %w", err)
+	}
+
+	v.deviceKey = privateKey
+	v.deviceKeyID = crypto.X25519KeyID(publicKey)
+	return nil
+}
+
 // DecodeLicense 解码许可证（不验证设备ID）
 // 用于调试和诊断，可以查看许可证中的设备ID等信息
 // 参数：
@@ -59,6 +166,8 @@ func (v *OfflineVerifier) DecodeLicense(licenseKey string) (*License, error) {
 }
 
 // Verify 验证离线许可证
+// 激活数限制只由授权服务器执行：离线验证不检查多设备许可证的设备ID，也无法统计激活数，
+// 多设备许可证只签发给网络验证和双重验证类型，应用必须同时进行网络验证或双重验证
 // 参数：
 //   - licenseKey: 许可证密钥（base64编码）
 //   - deviceID: 设备ID
@@ -78,32 +187,114 @@ func (v *OfflineVerifier) Verify(licenseKey string, deviceID string) (*VerifyRes
 		return nil, fmt.Errorf("decoded license is nil")
 	}
 
-	// 检查设备ID
-	if license.DeviceID != deviceID {
+	// 检查设备ID（多设备许可证和未绑定设备的试用许可证见 MatchesDevice）
+	if !license.MatchesDevice(deviceID) {
 		return nil, ErrDeviceMismatch
 	}
 
-	// 检查是否过期
+	// 检查产品和版本
+	if err := v.checkProduct(license); err != nil {
+		return nil, err
+	}
+
+	// 检查系统时间是否回拨（回拨后所有基于时间的检查都不可信）
 	now := time.Now()
-	expired := now.After(license.ExpiryDate)
+	if err := v.checkClock(now, license, deviceID); err != nil {
+		return nil, err
+	}
+
+	// 检查吊销列表
+	revoked, err := v.checkCRL(license, licenseKey, now)
+	if err != nil {
+		return nil, err
+	}
+	if revoked != nil {
+		return &VerifyResult{
+			LicenseID:    license.ID,
+			Valid:        false,
+			Revoked:      true,
+			ExpiryDate:   license.ExpiryDate,
+			DeviceID:     license.DeviceID,
+			LicenseType:  string(license.LicenseType),
+			Features:     license.Features,
+			Entitlements: license.Entitlements,
+			Reason:       ReasonLicenseRevoked,
+			Message:      "License revoked",
+		}, ErrRevokedLicense
+	}
 
 	result := &VerifyResult{
-		Valid:       !expired,
-		Expired:     expired,
-		ExpiryDate:  license.ExpiryDate,
-		DeviceID:    license.DeviceID,
-		LicenseType: string(license.LicenseType),
-		Message:     "Offline verification",
+		LicenseID:        license.ID,
+		ExpiryDate:       license.ExpiryDate,
+		NotBefore:        license.NotBefore,
+		Perpetual:        license.Perpetual,
+		MaintenanceUntil: license.MaintenanceUntil,
+		DeviceID:         license.DeviceID,
+		LicenseType:      string(license.LicenseType),
+		Features:         license.Features,
+		Entitlements:     license.Entitlements,
+		Message:          "Offline verification",
 	}
 
-	if expired {
+	// 检查是否已到生效时间
+	if license.IsNotYetValid(now) {
+		result.Reason = ReasonNotYetValid
+		result.Message = "License not yet valid"
+		return result, ErrLicenseNotYetValid
+	}
+
+	// 试用许可证的有效期从本设备首次激活开始计算
+	if license.IsTrial() {
+		startedAt, err := v.trialStart(license, deviceID, now)
+		if err != nil {
+			return nil, err
+		}
+		result.ExpiryDate = license.TrialExpiry(startedAt)
+	}
+
+	// 检查是否过期（宽限期内仍然有效）
+	v.expiryPolicy.Apply(result, now)
+	result.Valid = !result.Expired
+
+	if result.Expired {
 		result.Message = "License expired"
 		return result, ErrExpiredLicense
 	}
+	if result.InGracePeriod {
+		result.Message = "License expired, in grace period"
+	}
+
+	// 记录本次验证见到的时间，用于检测之后的时钟回拨
+	if err := v.recordClock(now, license); err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
 
+// checkProduct 检查许可证是否签发给验证器所在的产品和版本（未调用 SetProduct 时不检查）
+func (v *OfflineVerifier) checkProduct(license *License) error {
+	if v.productID == "" {
+		return nil
+	}
+	if license.ProductID != v.productID {
+		return ErrProductMismatch
+	}
+	if license.VersionRange == "" {
+		return nil
+	}
+
+	versionRange, err := ParseVersionRange(license.VersionRange)
+	if err != nil || v.productVersion == "" {
+		return ErrProductMismatch
+	}
+	ok, err := versionRange.Contains(v.productVersion)
+	if err != nil || !ok {
+		return ErrProductMismatch
+	}
+	return nil
+}
+
 // decodeLicense 解码许可证密钥
 // 参数：
 //   - licenseKey: base64编码的许可证密钥
@@ -118,30 +309,33 @@ func (v *OfflineVerifier) decodeLicense(licenseKey string) (*License, error) {
 		return nil, fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	// 提取签名和密文（RSA-4096签名长度为512字节）
-	signatureSize := 512
-	if len(licenseData) < signatureSize {
-		return nil, ErrInvalidLicense
+	// 解析许可证容器（兼容旧版 v0 格式）
+	envelope, err := ParseEnvelope(licenseData)
+	if err != nil {
+		return nil, err
 	}
 
-	signature := licenseData[:signatureSize]
-	encryptedData := licenseData[signatureSize:]
-
-	// 检查公钥是否为 nil（防御性编程）
-	if v.publicKey == nil {
+	// 检查公钥集合是否为 nil（防御性编程）
+	if v.keys == nil {
 		return nil, fmt.Errorf("public key is nil")
 	}
 
-	// 验证签名
-	valid, err := crypto.VerifySignature(encryptedData, signature, v.publicKey)
-	if err != nil || !valid {
-		return nil, ErrInvalidLicense
+	// 加密的许可证必须使用本验证器持有的密钥加密
+	switch envelope.EncryptionAlgorithm {
+	case EncryptionAES256GCM:
+		if envelope.EncryptionKeyID != v.encryptionKeyID {
+			return nil, fmt.Errorf("%w: %q", ErrEncryptionKeyMismatch, envelope.EncryptionKeyID)
+		}
+	case EncryptionX25519AES256GCM:
+		if v.deviceKey != nil && envelope.EncryptionKeyID != v.deviceKeyID {
+			return nil, fmt.Errorf("%w: device key %s", ErrEncryptionKeyMismatch, envelope.EncryptionKeyID)
+		}
 	}
 
-	// 使用AES解密
-	jsonData, err := crypto.DecryptAES(encryptedData, v.aesKey)
+	// 验证签名并解密
+	jsonData, err := envelope.OpenWith(v.keys, v.aesKey, v.deviceKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt AES: %w", err)
+		return nil, err
 	}
 
 	// 反序列化
@@ -3,6 +3,7 @@ package license
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 type OnlineConfig struct {
 	APIURL  string // API地址（必须）
 	AppID   string // 应用ID（必须）
+	Token   string // API Token（作为 Bearer Token 发送）
 	Timeout int    // 超时时间（秒）
 	Retries int    // 重试次数
 }
@@ -70,12 +72,26 @@ func (v *OnlineVerifier) Verify(deviceID string) (*VerifyResult, error) {
 	
 	// 发送请求
 	url := fmt.Sprintf("%s/license/verify/online", v.config.APIURL)
-	resp, err := v.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if v.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+v.config.Token)
+	}
+	
+	resp, err := v.client.Do(req)
 	if err != nil {
 		return nil, ErrNetworkError
 	}
 	defer resp.Body.Close()
 	
+	// Token无效或无权访问该应用
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrUnauthorized
+	}
+	
 	// 读取响应
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -93,7 +109,227 @@ func (v *OnlineVerifier) Verify(deviceID string) (*VerifyResult, error) {
 		return nil, ErrNetworkError
 	}
 	
+	// 许可证已被服务器撤销
+	if result.Reason == ReasonLicenseRevoked {
+		result.Revoked = true
+		return &result, ErrRevokedLicense
+	}
+	
 	return &result, nil
 }
 
+// RegisterDevice 向授权服务器注册设备
+// 提交设备公钥后，服务器签发给该设备的许可证加密到该公钥（见 device.LoadOrCreateKeyPair）
+// 参数：
+//   - deviceID: 设备ID
+//   - deviceName: 设备名称
+//   - publicKey: 设备X25519公钥（32字节，可为 nil）
+// 返回值：
+//   - error: 注册过程中的错误，设备已注册时返回 ErrDeviceRegistered
+func (v *OnlineVerifier) RegisterDevice(deviceID, deviceName string, publicKey []byte) error {
+	reqBody := map[string]string{
+		"device_id":   deviceID,
+		"device_name": deviceName,
+		"app_id":      v.config.AppID,
+	}
+	if publicKey != nil {
+		reqBody["public_key"] = base64.StdEncoding.EncodeToString(publicKey)
+	}
+	
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+	
+	url := fmt.Sprintf("%s/device/register", v.config.APIURL)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if v.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+v.config.Token)
+	}
+	
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return ErrNetworkError
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode == http.StatusConflict {
+		return ErrDeviceRegistered
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%w: device registration returned HTTP %d", ErrNetworkError, resp.StatusCode)
+	}
+	
+	return nil
+}
+
+// Activation 多设备许可证的激活结果
+type Activation struct {
+	LicenseID      string    `json:"license_id"`      // 许可证ID
+	DeviceID       string    `json:"device_id"`       // 设备ID
+	ActivatedAt    time.Time `json:"activated_at"`    // 激活时间
+	Activations    int       `json:"activations"`     // 已占用的激活名额
+	MaxActivations int       `json:"max_activations"` // 最大激活设备数
+}
+
+// Activate 在多设备许可证上激活当前设备
+// 许可证ID可以通过离线验证结果的 LicenseID 获取；同一设备重复激活不占用新的名额
+// 参数：
+//   - licenseID: 许可证ID
+//   - deviceID: 设备ID
+//   - deviceName: 设备名称
+// 返回值：
+//   - *Activation: 激活结果
+//   - error: 激活数已达上限时返回 ErrActivationLimit
+func (v *OnlineVerifier) Activate(licenseID, deviceID, deviceName string) (*Activation, error) {
+	var activation Activation
+	err := postJSON(v.client, v.config, "/license/activate", map[string]string{
+		"license_id":  licenseID,
+		"device_id":   deviceID,
+		"device_name": deviceName,
+		"app_id":      v.config.AppID,
+	}, &activation)
+	if err != nil {
+		return nil, err
+	}
+	return &activation, nil
+}
+
+// Deactivate 停用设备，释放其占用的激活名额（例如更换电脑前）
+// 参数：
+//   - licenseID: 许可证ID
+//   - deviceID: 设备ID
+// 返回值：
+//   - error: 设备未激活该许可证时返回 ErrNotActivated
+func (v *OnlineVerifier) Deactivate(licenseID, deviceID string) error {
+	var resp map[string]interface{}
+	return postJSON(v.client, v.config, "/license/deactivate", map[string]string{
+		"license_id": licenseID,
+		"device_id":  deviceID,
+		"app_id":     v.config.AppID,
+	}, &resp)
+}
 
+// Trial 设备的试用信息
+type Trial struct {
+	LicenseID  string    `json:"license_id"`  // 试用许可证ID
+	DeviceID   string    `json:"device_id"`   // 设备ID
+	StartedAt  time.Time `json:"started_at"`  // 首次激活时间（由服务器记录）
+	ExpiryDate time.Time `json:"expiry_date"` // 试用到期时间
+}
+
+// StartTrial 在授权服务器上为当前设备开始试用
+// 首次激活时间由服务器记录，重复调用返回已有的试用信息；每台设备只能试用一次
+// 参数：
+//   - licenseID: 试用许可证ID
+//   - deviceID: 设备ID（硬件指纹，见 device.GetDeviceID）
+//   - deviceName: 设备名称
+// 返回值：
+//   - *Trial: 试用信息
+//   - error: 该设备已试用过其他许可证时返回 ErrTrialUsed
+func (v *OnlineVerifier) StartTrial(licenseID, deviceID, deviceName string) (*Trial, error) {
+	var trial Trial
+	err := postJSON(v.client, v.config, "/license/trial", map[string]string{
+		"license_id":  licenseID,
+		"device_id":   deviceID,
+		"device_name": deviceName,
+		"app_id":      v.config.AppID,
+	}, &trial)
+	if err != nil {
+		return nil, err
+	}
+	return &trial, nil
+}
+
+// postJSON 发送POST请求并解析响应
+// 服务器返回的错误代码映射为对应的错误
+// 参数：
+//   - client: HTTP客户端
+//   - config: 网络验证配置
+//   - path: API路径（相对于 APIURL）
+//   - reqBody: 请求体
+//   - out: 成功时解析响应的目标
+// 返回值：
+//   - error: 请求过程中的错误
+func postJSON(client *http.Client, config *OnlineConfig, path string, reqBody interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, config.APIURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+config.Token)
+	}
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return ErrNetworkError
+	}
+	defer resp.Body.Close()
+	
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ErrNetworkError
+	}
+	
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(body, out); err != nil {
+			return ErrNetworkError
+		}
+		return nil
+	}
+	
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &errResp)
+	
+	switch errResp.Error.Code {
+	case "NO_SEATS_AVAILABLE":
+		return ErrNoSeatsAvailable
+	case "LEASE_NOT_FOUND":
+		return ErrLeaseLost
+	case "ACTIVATION_LIMIT":
+		return ErrActivationLimit
+	case "NOT_ACTIVATED":
+		return ErrNotActivated
+	case "LICENSE_NOT_FOUND":
+		return ErrLicenseNotFound
+	case "LICENSE_REVOKED":
+		return ErrRevokedLicense
+	case "LICENSE_EXPIRED":
+		return ErrExpiredLicense
+	case "LICENSE_NOT_YET_VALID":
+		return ErrLicenseNotYetValid
+	case "NOT_FLOATING":
+		return fmt.Errorf("%w: not a floating license", ErrInvalidLicense)
+	case "NOT_MULTI_DEVICE":
+		return fmt.Errorf("%w: not a multi-device license", ErrInvalidLicense)
+	case "NOT_TRIAL":
+		return fmt.Errorf("%w: not a trial license", ErrInvalidLicense)
+	case "TRIAL_USED":
+		return ErrTrialUsed
+	case "DEVICE_MISMATCH":
+		return ErrDeviceMismatch
+	}
+	
+	// Token无效或无权访问该应用
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	}
+	return fmt.Errorf("%w: %s returned HTTP %d", ErrNetworkError, path, resp.StatusCode)
+}
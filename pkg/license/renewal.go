@@ -0,0 +1,212 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+// renewalFile 待处理的续期请求状态文件名
+const renewalFile = "renewal.json"
+
+// RenewalRequest 离线续期请求（请求文件为其JSON序列化结果）
+// 无法访问授权服务器的客户端生成请求文件，由操作员带到服务器端签发响应许可证（见 CreateRenewalRequest、InstallRenewal）。
+// 请求使用由客户端AES密钥（产品许可证为产品密钥）派生的密钥计算MAC，服务器端验证MAC后才处理请求（见 VerifyMAC）；
+// AES密钥随客户端分发，MAC只能证明请求来自持有该密钥的客户端，不能区分同一产品的不同设备，
+// 因此服务器端只接受设备已登记的公钥（见 licensemanager device bind --public-key），不会登记请求中的公钥
+type RenewalRequest struct {
+	DeviceID  string    `json:"device_id"`     // 设备ID（设备指纹，见 device.GetDeviceID）
+	LicenseID string    `json:"license_id"`    // 当前许可证ID
+	Nonce     string    `json:"nonce"`         // 随机数（响应许可证的 RequestNonce 必须与之一致）
+	PublicKey []byte    `json:"public_key"`    // 设备X25519公钥（响应许可证加密到该公钥）
+	CreatedAt time.Time `json:"created_at"`    // 生成时间
+	MAC       []byte    `json:"mac,omitempty"` // 请求其余字段的HMAC-SHA256（见 VerifyMAC）
+}
+
+// renewalState 待处理的续期请求（保存在客户端状态目录中）
+type renewalState struct {
+	LicenseID string    `json:"license_id"` // 请求续期的许可证ID
+	Nonce     string    `json:"nonce"`      // 请求的随机数
+	CreatedAt time.Time `json:"created_at"` // 生成时间
+}
+
+// CreateRenewalRequest 生成离线续期请求文件
+// 随机数保存在状态目录中，之后只能安装响应该请求的许可证（见 InstallRenewal）；
+// 再次生成请求会替换之前待处理的请求。需要先调用 SetDeviceKey 和 SetStateDir，
+// 验证器必须持有AES密钥（产品许可证为产品密钥），用于计算请求的MAC
+// 参数：
+//   - deviceID: 设备ID
+//   - licenseID: 当前许可证ID（见 DecodeLicense）
+//
+// 返回值：
+//   - []byte: 请求文件内容（RenewalRequest 的JSON）
+//   - error: 未设置设备私钥（ErrDeviceKeyRequired）或状态目录（ErrStateRequired）时的错误
+func (v *OfflineVerifier) CreateRenewalRequest(deviceID, licenseID string) ([]byte, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("license ID is required")
+	}
+	if v.deviceKey == nil {
+		return nil, ErrDeviceKeyRequired
+	}
+	if v.aesKey == nil {
+		return nil, fmt.Errorf("renewal requests require the AES key")
+	}
+
+	store, err := v.stateStore(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := crypto.X25519PublicKey(v.deviceKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device key: %w", err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	req := RenewalRequest{
+		DeviceID:  deviceID,
+		LicenseID: licenseID,
+		Nonce:     hex.EncodeToString(nonce),
+		PublicKey: publicKey,
+		CreatedAt: time.Now().UTC(),
+	}
+	req.MAC, err = req.mac(v.aesKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	state := renewalState{LicenseID: req.LicenseID, Nonce: req.Nonce, CreatedAt: req.CreatedAt}
+	if err := store.save(renewalFile, &state); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseRenewalRequest 解析离线续期请求文件（服务器端使用）
+// 只校验格式，处理请求前还需要使用许可证对应的AES密钥验证MAC（见 VerifyMAC）
+// 参数：
+//   - data: 请求文件内容（RenewalRequest 的JSON）
+//
+// 返回值：
+//   - *RenewalRequest: 续期请求
+//   - error: 格式错误时返回 ErrInvalidRenewalRequest
+func ParseRenewalRequest(data []byte) (*RenewalRequest, error) {
+	var req RenewalRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRenewalRequest, err)
+	}
+
+	switch {
+	case req.DeviceID == "" || req.LicenseID == "" || req.Nonce == "":
+		return nil, fmt.Errorf("%w: device ID, license ID and nonce are required", ErrInvalidRenewalRequest)
+	case len(req.PublicKey) != 32:
+		return nil, fmt.Errorf("%w: invalid device public key", ErrInvalidRenewalRequest)
+	}
+
+	return &req, nil
+}
+
+// VerifyMAC 验证续期请求的MAC（服务器端使用）
+// 参数：
+//   - aesKey: 请求续期的许可证对应的AES密钥（产品许可证为派生的产品密钥，见 crypto.DeriveProductKey）
+//
+// 返回值：
+//   - error: 缺少MAC或MAC不一致时返回 ErrInvalidRenewalRequest
+func (r *RenewalRequest) VerifyMAC(aesKey []byte) error {
+	mac, err := r.mac(aesKey)
+	if err != nil {
+		return err
+	}
+	if len(r.MAC) == 0 || !hmac.Equal(r.MAC, mac) {
+		return fmt.Errorf("%w: MAC verification failed", ErrInvalidRenewalRequest)
+	}
+	return nil
+}
+
+// mac 计算请求除 MAC 以外字段的HMAC-SHA256
+func (r *RenewalRequest) mac(aesKey []byte) ([]byte, error) {
+	key, err := crypto.DeriveRenewalKey(aesKey)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned := *r
+	unsigned.MAC = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	m := hmac.New(sha256.New, key)
+	m.Write(data)
+	return m.Sum(nil), nil
+}
+
+// InstallRenewal 安装续期响应许可证
+// 响应许可证必须响应本设备待处理的续期请求（RequestNonce 一致）且验证通过，安装后写入许可证文件并清除待处理的请求，
+// 同一个响应不能重复安装
+// 参数：
+//   - responseKey: 响应许可证密钥（base64编码）
+//   - deviceID: 设备ID
+//   - licensePath: 许可证文件路径（已存在时替换）
+//
+// 返回值：
+//   - *VerifyResult: 响应许可证的验证结果
+//   - error: 没有待处理的请求或响应不匹配时返回 ErrRenewalMismatch，验证失败时返回验证错误
+func (v *OfflineVerifier) InstallRenewal(responseKey, deviceID, licensePath string) (*VerifyResult, error) {
+	store, err := v.stateStore(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var state renewalState
+	found, err := store.load(renewalFile, &state)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no pending renewal request", ErrRenewalMismatch)
+	}
+
+	lic, err := v.decodeLicense(responseKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode license: %w", err)
+	}
+	if lic.RequestNonce == "" || lic.RequestNonce != state.Nonce {
+		return nil, ErrRenewalMismatch
+	}
+
+	result, err := v.Verify(responseKey, deviceID)
+	if err != nil {
+		return result, err
+	}
+
+	// 先写入临时文件再重命名，避免写入中断导致原许可证丢失
+	if err := os.WriteFile(licensePath+".tmp", []byte(responseKey), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write license file: %w", err)
+	}
+	if err := os.Rename(licensePath+".tmp", licensePath); err != nil {
+		return nil, fmt.Errorf("failed to write license file: %w", err)
+	}
+
+	if err := store.remove(renewalFile); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
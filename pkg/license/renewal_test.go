@@ -0,0 +1,137 @@
+package license
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+)
+
+func TestParseRenewalRequest(t *testing.T) {
+	publicKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, 32))
+	shortKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, 16))
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"device_id":"d","license_id":"l","nonce":"n","public_key":"` + publicKey + `"}`, false},
+		{"missing device", `{"license_id":"l","nonce":"n","public_key":"` + publicKey + `"}`, true},
+		{"missing license", `{"device_id":"d","nonce":"n","public_key":"` + publicKey + `"}`, true},
+		{"missing nonce", `{"device_id":"d","license_id":"l","public_key":"` + publicKey + `"}`, true},
+		{"missing public key", `{"device_id":"d","license_id":"l","nonce":"n"}`, true},
+		{"short public key", `{"device_id":"d","license_id":"l","nonce":"n","public_key":"` + shortKey + `"}`, true},
+		{"invalid base64", `{"device_id":"d","license_id":"l","nonce":"n","public_key":"!"}`, true},
+		{"not json", `renewal`, true},
+		{"empty", ``, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := ParseRenewalRequest([]byte(tc.data))
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidRenewalRequest) {
+					t.Fatalf("ParseRenewalRequest: err = %v, want ErrInvalidRenewalRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRenewalRequest: %v", err)
+			}
+			if req.DeviceID != "d" || req.LicenseID != "l" || req.Nonce != "n" || len(req.PublicKey) != 32 {
+				t.Fatalf("ParseRenewalRequest = %+v", req)
+			}
+		})
+	}
+}
+
+func TestCreateRenewalRequest(t *testing.T) {
+	deviceKey, devicePublicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("GenerateX25519KeyPair: %v", err)
+	}
+
+	aesKey := bytes.Repeat([]byte{0x01}, 32)
+
+	// 请求的MAC需要AES密钥，只持有公钥的验证器不能生成请求
+	if _, err := newStateVerifier(t, t.TempDir(), nil, deviceKey).CreateRenewalRequest("device-a", "lic-1"); err == nil {
+		t.Fatal("CreateRenewalRequest without AES key succeeded, want error")
+	}
+
+	v := newStateVerifier(t, t.TempDir(), aesKey, nil)
+	if _, err := v.CreateRenewalRequest("device-a", "lic-1"); !errors.Is(err, ErrDeviceKeyRequired) {
+		t.Fatalf("CreateRenewalRequest without device key: err = %v, want ErrDeviceKeyRequired", err)
+	}
+	if err := v.SetDeviceKey(deviceKey); err != nil {
+		t.Fatalf("SetDeviceKey: %v", err)
+	}
+	if _, err := v.CreateRenewalRequest("device-a", ""); err == nil {
+		t.Fatal("CreateRenewalRequest without license ID succeeded, want error")
+	}
+
+	data, err := v.CreateRenewalRequest("device-a", "lic-1")
+	if err != nil {
+		t.Fatalf("CreateRenewalRequest: %v", err)
+	}
+	req, err := ParseRenewalRequest(data)
+	if err != nil {
+		t.Fatalf("ParseRenewalRequest: %v", err)
+	}
+	if req.DeviceID != "device-a" || req.LicenseID != "lic-1" || !bytes.Equal(req.PublicKey, devicePublicKey) {
+		t.Fatalf("ParseRenewalRequest = %+v", req)
+	}
+
+	// MAC只能由持有相同AES密钥的一方验证，修改请求内容后验证失败
+	if err := req.VerifyMAC(aesKey); err != nil {
+		t.Fatalf("VerifyMAC: %v", err)
+	}
+	if err := req.VerifyMAC(bytes.Repeat([]byte{0x02}, 32)); !errors.Is(err, ErrInvalidRenewalRequest) {
+		t.Fatalf("VerifyMAC with other key: err = %v, want ErrInvalidRenewalRequest", err)
+	}
+	tampered := *req
+	tampered.PublicKey = bytes.Repeat([]byte{0x03}, 32)
+	if err := tampered.VerifyMAC(aesKey); !errors.Is(err, ErrInvalidRenewalRequest) {
+		t.Fatalf("VerifyMAC after tampering: err = %v, want ErrInvalidRenewalRequest", err)
+	}
+	unsigned := *req
+	unsigned.MAC = nil
+	if err := unsigned.VerifyMAC(aesKey); !errors.Is(err, ErrInvalidRenewalRequest) {
+		t.Fatalf("VerifyMAC without MAC: err = %v, want ErrInvalidRenewalRequest", err)
+	}
+
+	// 待处理的请求记录在状态目录中
+	store, err := v.stateStore("device-a")
+	if err != nil {
+		t.Fatalf("stateStore: %v", err)
+	}
+	var state renewalState
+	if found, err := store.load(renewalFile, &state); err != nil || !found {
+		t.Fatalf("load pending request = %v, %v; want true, nil", found, err)
+	}
+	if state.Nonce != req.Nonce || state.LicenseID != req.LicenseID {
+		t.Fatalf("pending request = %+v, want nonce %s", state, req.Nonce)
+	}
+
+	// 再次生成请求替换待处理的请求
+	data, err = v.CreateRenewalRequest("device-a", "lic-1")
+	if err != nil {
+		t.Fatalf("CreateRenewalRequest: %v", err)
+	}
+	next, err := ParseRenewalRequest(data)
+	if err != nil {
+		t.Fatalf("ParseRenewalRequest: %v", err)
+	}
+	if next.Nonce == req.Nonce {
+		t.Fatal("CreateRenewalRequest reused the nonce")
+	}
+	if _, err := store.load(renewalFile, &state); err != nil || state.Nonce != next.Nonce {
+		t.Fatalf("pending request nonce = %s, %v; want %s", state.Nonce, err, next.Nonce)
+	}
+
+	// 没有匹配的待处理请求时拒绝安装
+	if _, err := newStateVerifier(t, t.TempDir(), nil, deviceKey).InstallRenewal("", "device-a", "license.key"); !errors.Is(err, ErrRenewalMismatch) {
+		t.Fatalf("InstallRenewal without pending request: err = %v, want ErrRenewalMismatch", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stateStore 客户端状态文件存储（试用激活时间、时钟状态等）
+// 状态文件使用HMAC-SHA256防篡改，MAC绑定设备ID，复制到其他设备或修改内容后校验失败；
+// MAC密钥由随客户端分发的密钥派生，持有该密钥的人可以伪造状态文件。客户端无法阻止删除整个状态目录，
+// 一台设备只能试用一次由授权服务器保证
+type stateStore struct {
+	dir      string // 状态目录
+	key      []byte // MAC密钥
+	deviceID string // 状态所属的设备ID
+}
+
+// stateFile 状态文件格式
+type stateFile struct {
+	Data json.RawMessage `json:"data"` // 状态内容
+	MAC  string          `json:"mac"`  // base64编码的HMAC-SHA256
+}
+
+// SetStateDir 设置客户端状态目录
+// 离线验证试用许可证时在该目录中记录首次激活时间，启用时钟回拨检测时记录见到的最晚时间（见 EnableClockCheck）；
+// 状态文件的MAC密钥由AES密钥或设备私钥派生，只持有公钥的验证器使用状态文件时返回 ErrStateKeyRequired；
+// 持有这些密钥的人可以伪造状态文件，删除整个状态目录会重置试用和时钟回拨检测，需要严格限制时应配合网络验证或双重验证使用；
+// 目录不存在时自动创建（仅所有者可访问）
+// 参数：
+//   - dir: 状态目录
+//
+// 返回值：
+//   - error: 创建目录失败时的错误
+func (v *OfflineVerifier) SetStateDir(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("state directory is required")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	v.stateDir = dir
+	return nil
+}
+
+// stateStore 返回设备的状态存储
+// MAC密钥由验证器持有的AES密钥和设备私钥派生，公钥是公开的，只持有公钥的验证器无法防止伪造状态文件，返回 ErrStateKeyRequired
+func (v *OfflineVerifier) stateStore(deviceID string) (*stateStore, error) {
+	if v.stateDir == "" {
+		return nil, ErrStateRequired
+	}
+	if !v.hasStateKey() {
+		return nil, ErrStateKeyRequired
+	}
+
+	// 每部分带长度前缀，避免不同的AES密钥和设备私钥拼接后相同
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte("LicenseManager state v2"), v.aesKey, v.deviceKey} {
+		binary.Write(h, binary.BigEndian, uint32(len(part)))
+		h.Write(part)
+	}
+
+	return &stateStore{dir: v.stateDir, key: h.Sum(nil), deviceID: deviceID}, nil
+}
+
+// hasStateKey 判断验证器是否持有派生状态文件MAC密钥的秘密（AES密钥或设备私钥）
+func (v *OfflineVerifier) hasStateKey() bool {
+	return len(v.aesKey) > 0 || len(v.deviceKey) > 0
+}
+
+// mac 计算状态内容的MAC（绑定设备ID）
+func (s *stateStore) mac(data []byte) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(s.deviceID))
+	m.Write([]byte{0})
+	m.Write(data)
+	return m.Sum(nil)
+}
+
+// load 读取并校验状态文件
+// 返回值：
+//   - bool: 状态文件是否存在
+//   - error: 读取失败或校验失败（ErrStateTampered）时的错误
+func (s *stateStore) load(name string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return false, ErrStateTampered
+	}
+	mac, err := base64.StdEncoding.DecodeString(file.MAC)
+	if err != nil || !hmac.Equal(mac, s.mac(file.Data)) {
+		return false, ErrStateTampered
+	}
+	if err := json.Unmarshal(file.Data, v); err != nil {
+		return false, ErrStateTampered
+	}
+	return true, nil
+}
+
+// save 写入状态文件（先写入临时文件再重命名，避免写入中断导致文件损坏）
+func (s *stateStore) save(name string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(stateFile{
+		Data: payload,
+		MAC:  base64.StdEncoding.EncodeToString(s.mac(payload)),
+	})
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// remove 删除状态文件（文件不存在时不返回错误）
+func (s *stateStore) remove(name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state file: %w", err)
+	}
+	return nil
+}
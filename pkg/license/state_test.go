@@ -0,0 +1,279 @@
+package license
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStateVerifier 创建使用临时状态目录的验证器
+func newStateVerifier(t *testing.T, dir string, aesKey, deviceKey []byte) *OfflineVerifier {
+	t.Helper()
+	v := &OfflineVerifier{aesKey: aesKey, deviceKey: deviceKey}
+	if err := v.SetStateDir(dir); err != nil {
+		t.Fatalf("SetStateDir: %v", err)
+	}
+	return v
+}
+
+func TestStateStoreMAC(t *testing.T) {
+	aesKey := bytes.Repeat([]byte{0x01}, 32)
+	deviceKey := bytes.Repeat([]byte{0x02}, 32)
+	state := trialState{LicenseID: "lic-1", StartedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name      string
+		aesKey    []byte
+		deviceKey []byte
+		deviceID  string
+		modify    func(t *testing.T, path string)
+		wantErr   error
+	}{
+		{"same verifier", aesKey, deviceKey, "device-a", nil, nil},
+		{"other device", aesKey, deviceKey, "device-b", nil, ErrStateTampered},
+		{"other aes key", bytes.Repeat([]byte{0x03}, 32), deviceKey, "device-a", nil, ErrStateTampered},
+		{"other device key", aesKey, bytes.Repeat([]byte{0x03}, 32), "device-a", nil, ErrStateTampered},
+		{"missing device key", aesKey, nil, "device-a", nil, ErrStateTampered},
+		// 密钥各部分带长度前缀：AES密钥和设备私钥之间移动字节后派生的密钥不同
+		{"shifted key parts", append(bytes.Clone(aesKey), deviceKey[0]), deviceKey[1:], "device-a", nil, ErrStateTampered},
+		{"modified data", aesKey, deviceKey, "device-a", func(t *testing.T, path string) {
+			rewriteState(t, path, func(file *stateFile) {
+				file.Data = bytes.Replace(file.Data, []byte("2025"), []byte("2030"), 1)
+			})
+		}, ErrStateTampered},
+		{"modified mac", aesKey, deviceKey, "device-a", func(t *testing.T, path string) {
+			rewriteState(t, path, func(file *stateFile) {
+				file.MAC = "AAAA" + file.MAC[4:]
+			})
+		}, ErrStateTampered},
+		{"invalid mac encoding", aesKey, deviceKey, "device-a", func(t *testing.T, path string) {
+			rewriteState(t, path, func(file *stateFile) {
+				file.MAC = "!"
+			})
+		}, ErrStateTampered},
+		{"corrupted file", aesKey, deviceKey, "device-a", func(t *testing.T, path string) {
+			if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+		}, ErrStateTampered},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := newStateVerifier(t, dir, aesKey, deviceKey).stateStore("device-a")
+			if err != nil {
+				t.Fatalf("stateStore: %v", err)
+			}
+			if err := store.save("state.json", &state); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			if tc.modify != nil {
+				tc.modify(t, filepath.Join(dir, "state.json"))
+			}
+
+			other, err := newStateVerifier(t, dir, tc.aesKey, tc.deviceKey).stateStore(tc.deviceID)
+			if err != nil {
+				t.Fatalf("stateStore: %v", err)
+			}
+			var got trialState
+			found, err := other.load("state.json", &got)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("load: err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && (!found || got != state) {
+				t.Fatalf("load = %+v, found %v; want %+v", got, found, state)
+			}
+		})
+	}
+
+	// 状态文件不存在
+	store, err := newStateVerifier(t, t.TempDir(), aesKey, nil).stateStore("device-a")
+	if err != nil {
+		t.Fatalf("stateStore: %v", err)
+	}
+	var got trialState
+	if found, err := store.load("missing.json", &got); found || err != nil {
+		t.Fatalf("load missing = %v, %v; want false, nil", found, err)
+	}
+}
+
+// rewriteState 修改已保存的状态文件
+func rewriteState(t *testing.T, path string, modify func(file *stateFile)) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	modify(&file)
+	data, err = json.Marshal(file)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestStateKeyRequired(t *testing.T) {
+	// 只持有公钥的验证器不能使用状态文件
+	publicOnly := newStateVerifier(t, t.TempDir(), nil, nil)
+	if _, err := publicOnly.stateStore("device-a"); !errors.Is(err, ErrStateKeyRequired) {
+		t.Fatalf("stateStore: err = %v, want ErrStateKeyRequired", err)
+	}
+	if err := publicOnly.EnableClockCheck(0); !errors.Is(err, ErrStateKeyRequired) {
+		t.Fatalf("EnableClockCheck: err = %v, want ErrStateKeyRequired", err)
+	}
+	lic := &License{ID: "lic-1", LicenseType: LicenseTypeTrial}
+	if _, err := publicOnly.trialStart(lic, "device-a", time.Now()); !errors.Is(err, ErrStateKeyRequired) {
+		t.Fatalf("trialStart: err = %v, want ErrStateKeyRequired", err)
+	}
+
+	if err := (&OfflineVerifier{aesKey: make([]byte, 32)}).EnableClockCheck(0); !errors.Is(err, ErrStateRequired) {
+		t.Fatalf("EnableClockCheck without state dir: err = %v, want ErrStateRequired", err)
+	}
+
+	// 设置设备私钥后可以使用状态文件
+	withDeviceKey := newStateVerifier(t, t.TempDir(), nil, bytes.Repeat([]byte{0x02}, 32))
+	if err := withDeviceKey.EnableClockCheck(0); err != nil {
+		t.Fatalf("EnableClockCheck with device key: %v", err)
+	}
+	if withDeviceKey.clockTolerance != DefaultClockTolerance {
+		t.Fatalf("clockTolerance = %v, want %v", withDeviceKey.clockTolerance, DefaultClockTolerance)
+	}
+}
+
+func TestTrialStart(t *testing.T) {
+	v := newStateVerifier(t, t.TempDir(), bytes.Repeat([]byte{0x01}, 32), nil)
+	lic := &License{ID: "lic-1", LicenseType: LicenseTypeTrial}
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	started, err := v.trialStart(lic, "device-a", first)
+	if err != nil {
+		t.Fatalf("trialStart: %v", err)
+	}
+	if !started.Equal(first) {
+		t.Fatalf("trialStart = %v, want %v", started, first)
+	}
+
+	// 之后的验证沿用首次激活时间
+	started, err = v.trialStart(lic, "device-a", first.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("trialStart: %v", err)
+	}
+	if !started.Equal(first) {
+		t.Fatalf("trialStart second run = %v, want %v", started, first)
+	}
+
+	// 状态文件复制到其他设备后校验失败
+	if _, err := v.trialStart(lic, "device-b", first); !errors.Is(err, ErrStateTampered) {
+		t.Fatalf("trialStart on other device: err = %v, want ErrStateTampered", err)
+	}
+}
+
+func TestCheckClock(t *testing.T) {
+	v := newStateVerifier(t, t.TempDir(), bytes.Repeat([]byte{0x01}, 32), nil)
+	if err := v.EnableClockCheck(time.Minute); err != nil {
+		t.Fatalf("EnableClockCheck: %v", err)
+	}
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	lic := &License{ID: "lic-1", CreatedAt: now.Add(-24 * time.Hour)}
+	if err := v.checkClock(now, lic, "device-a"); err != nil {
+		t.Fatalf("checkClock: %v", err)
+	}
+	if err := v.recordClock(now, lic); err != nil {
+		t.Fatalf("recordClock: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr error
+	}{
+		{"later", now.Add(time.Hour), nil},
+		{"within tolerance", now.Add(-30 * time.Second), nil},
+		{"set back", now.Add(-2 * time.Minute), ErrClockTampered},
+	}
+	for _, tc := range tests {
+		if err := v.checkClock(tc.now, lic, "device-a"); !errors.Is(err, tc.wantErr) {
+			t.Fatalf("checkClock %s: err = %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	// 没有时钟状态时，早于签发时间同样视为回拨
+	fresh := newStateVerifier(t, t.TempDir(), bytes.Repeat([]byte{0x01}, 32), nil)
+	if err := fresh.EnableClockCheck(time.Minute); err != nil {
+		t.Fatalf("EnableClockCheck: %v", err)
+	}
+	if err := fresh.checkClock(lic.CreatedAt.Add(-time.Hour), lic, "device-a"); !errors.Is(err, ErrClockTampered) {
+		t.Fatalf("checkClock before issue time: err = %v, want ErrClockTampered", err)
+	}
+}
+
+func TestMissingStateAfterSeen(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	lic := &License{ID: "trial-1", LicenseType: LicenseTypeTrial}
+
+	tests := []struct {
+		name    string
+		remove  string
+		check   func(v *OfflineVerifier) error
+		wantErr error
+	}{
+		{
+			name:   "clock state deleted",
+			remove: clockFile,
+			check: func(v *OfflineVerifier) error {
+				return v.checkClock(now, lic, "device-a")
+			},
+			wantErr: ErrClockTampered,
+		},
+		{
+			name:   "trial state deleted",
+			remove: trialFile(lic),
+			check: func(v *OfflineVerifier) error {
+				_, err := v.trialStart(lic, "device-a", now.Add(time.Hour))
+				return err
+			},
+			wantErr: ErrStateTampered,
+		},
+	}
+	for _, tc := range tests {
+		dir := t.TempDir()
+		v := newStateVerifier(t, dir, bytes.Repeat([]byte{0x01}, 32), nil)
+		if err := v.EnableClockCheck(time.Minute); err != nil {
+			t.Fatalf("%s: EnableClockCheck: %v", tc.name, err)
+		}
+		if _, err := v.trialStart(lic, "device-a", now); err != nil {
+			t.Fatalf("%s: trialStart: %v", tc.name, err)
+		}
+
+		if err := os.Remove(filepath.Join(dir, tc.remove)); err != nil {
+			t.Fatalf("%s: remove: %v", tc.name, err)
+		}
+		if err := tc.check(v); !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	// 未启用时钟回拨检测时记录的试用状态不会被误判为时钟状态被删除
+	dir := t.TempDir()
+	v := newStateVerifier(t, dir, bytes.Repeat([]byte{0x01}, 32), nil)
+	if _, err := v.trialStart(lic, "device-a", now); err != nil {
+		t.Fatalf("trialStart: %v", err)
+	}
+	if err := v.EnableClockCheck(time.Minute); err != nil {
+		t.Fatalf("EnableClockCheck: %v", err)
+	}
+	if err := v.checkClock(now, lic, "device-a"); err != nil {
+		t.Fatalf("checkClock after enabling: %v", err)
+	}
+}
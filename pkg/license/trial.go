@@ -0,0 +1,90 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// trialState 试用状态（保存在客户端状态目录中）
+type trialState struct {
+	LicenseID  string    `json:"license_id"`            // 试用许可证ID
+	StartedAt  time.Time `json:"started_at"`            // 首次激活时间
+	ClockCheck bool      `json:"clock_check,omitempty"` // 记录时是否启用了时钟回拨检测（同时记录了时钟状态）
+}
+
+// trialStart 返回本设备开始试用的时间，首次运行时记录当前时间
+// 参数：
+//   - lic: 试用许可证
+//   - deviceID: 设备ID
+//   - now: 当前时间
+//
+// 返回值：
+//   - time.Time: 首次激活时间
+//   - error: 未设置状态目录（ErrStateRequired）、只持有公钥（ErrStateKeyRequired）或状态文件被篡改、被删除（ErrStateTampered）时的错误
+func (v *OfflineVerifier) trialStart(lic *License, deviceID string, now time.Time) (time.Time, error) {
+	state, found, err := v.loadTrial(lic, deviceID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if found {
+		return state.StartedAt, nil
+	}
+
+	// 时钟状态记录过该许可证，试用状态却不存在：试用状态被删除，不能重新开始试用
+	clock, _, _, err := v.loadClock()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if slices.Contains(clock.Licenses, lic.ID) {
+		return time.Time{}, fmt.Errorf("%w: trial state missing", ErrStateTampered)
+	}
+
+	// 首次运行：从现在开始试用（试用截止日期之后由到期检查拒绝）
+	state = &trialState{LicenseID: lic.ID, StartedAt: now.UTC(), ClockCheck: v.clockCheck}
+	store, err := v.stateStore(deviceID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := store.save(trialFile(lic), state); err != nil {
+		return time.Time{}, err
+	}
+
+	// 同时记录时钟状态，之后验证失败（例如试用截止日期已过）时仍然可以发现时钟状态被删除
+	if err := v.recordClock(now, lic); err != nil {
+		return time.Time{}, err
+	}
+	return state.StartedAt, nil
+}
+
+// loadTrial 读取本设备的试用状态
+// 返回值：
+//   - *trialState: 试用状态
+//   - bool: 试用状态是否存在
+//   - error: 读取失败或状态文件被篡改（ErrStateTampered）时的错误
+func (v *OfflineVerifier) loadTrial(lic *License, deviceID string) (*trialState, bool, error) {
+	if lic.ID == "" {
+		return nil, false, ErrInvalidLicense
+	}
+
+	store, err := v.stateStore(deviceID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var state trialState
+	found, err := store.load(trialFile(lic), &state)
+	if err != nil {
+		return nil, false, err
+	}
+	if found && state.LicenseID != lic.ID {
+		return nil, false, ErrStateTampered
+	}
+	return &state, found, nil
+}
+
+// trialFile 返回试用状态文件名
+func trialFile(lic *License) string {
+	return "trial-" + lic.ID + ".json"
+}
@@ -15,27 +15,158 @@ const (
 	
 	// LicenseTypeDual 双重验证许可证
 	LicenseTypeDual LicenseType = "dual"
+	
+	// LicenseTypeFloating 浮动许可证（N个并发席位，客户端运行时从授权服务器签出席位）
+	LicenseTypeFloating LicenseType = "floating"
+	
+	// LicenseTypeTrial 试用许可证（不预先绑定设备，有效期从设备首次激活开始计算）
+	LicenseTypeTrial LicenseType = "trial"
 )
 
+// FormatVersion 当前许可证格式版本
+// 旧版许可证不包含版本号（解码后为0）
+const FormatVersion = 1
+
+// DefaultIssuer 默认签发者
+const DefaultIssuer = "LicenseManager"
+
 // License 许可证结构
 type License struct {
-	DeviceID    string      // 设备ID
-	ExpiryDate  time.Time   // 到期时间
-	LicenseType LicenseType // 许可证类型
-	Features    []string    // 功能列表
-	CreatedAt   time.Time   // 创建时间
+	ID               string       // 许可证ID（UUID，旧版许可证为空）
+	Issuer           string       // 签发者
+	Version          int          // 许可证格式版本（见 FormatVersion）
+	DeviceID         string       // 设备ID
+	ProductID        string       // 产品ID（为空表示不限产品）
+	VersionRange     string       // 适用的产品版本范围（见 ParseVersionRange，为空表示不限版本）
+	ExpiryDate       time.Time    // 到期时间（永久许可证为零值）
+	NotBefore        time.Time    // 生效时间（零值表示签发后立即生效）
+	Perpetual        bool         // 永久许可证（不过期，忽略 ExpiryDate）
+	MaintenanceUntil time.Time    // 维护截止日期（永久许可证可选，之后发布的版本不在授权范围内，见 VerifyResult.MaintenanceCovers）
+	LicenseType      LicenseType  // 许可证类型
+	Seats            int          // 并发席位数（仅浮动许可证）
+	MaxActivations   int          // 最大激活设备数（大于0时为多设备许可证，DeviceID 为客户ID）
+	TrialDays        int          // 试用天数（仅试用许可证，从首次激活开始计算，ExpiryDate 为试用截止日期）
+	Features         []string     // 功能列表
+	Entitlements     Entitlements // 授权项（功能开关、数量限制等，见 HasFeature、Limit）
+	RequestNonce     string       // 离线续期请求的随机数（仅响应续期请求签发的许可证，见 RenewalRequest）
+	CreatedAt        time.Time    // 创建时间
+}
+
+// IsMultiDevice 判断是否为多设备许可证
+// 多设备许可证不绑定单台设备，设备通过授权服务器激活（见 OnlineVerifier.Activate），激活数不超过 MaxActivations
+func (l *License) IsMultiDevice() bool {
+	return l.MaxActivations > 0
+}
+
+// IsExpired 判断许可证在指定时间是否已过期（永久许可证不过期）
+func (l *License) IsExpired(now time.Time) bool {
+	return !l.Perpetual && now.After(l.ExpiryDate)
+}
+
+// IsNotYetValid 判断许可证在指定时间是否尚未生效（早于 NotBefore）
+func (l *License) IsNotYetValid(now time.Time) bool {
+	return !l.NotBefore.IsZero() && now.Before(l.NotBefore)
+}
+
+// IsTrial 判断是否为试用许可证
+func (l *License) IsTrial() bool {
+	return l.LicenseType == LicenseTypeTrial
+}
+
+// MatchesDevice 判断许可证是否适用于设备
+// 多设备许可证（仅网络验证和双重验证类型）的设备绑定由授权服务器的激活记录控制，未指定设备ID的试用许可证适用于任何设备；
+// 其他类型的许可证即使设置了 MaxActivations 也按 DeviceID 严格匹配
+func (l *License) MatchesDevice(deviceID string) bool {
+	serverBound := l.LicenseType == LicenseTypeOnline || l.LicenseType == LicenseTypeDual
+	if (l.IsMultiDevice() && serverBound) || (l.IsTrial() && l.DeviceID == "") {
+		return true
+	}
+	return l.DeviceID == deviceID
+}
+
+// TrialExpiry 计算试用许可证的实际到期时间（首次激活时间 + 试用天数，不晚于 ExpiryDate）
+// 参数：
+//   - startedAt: 首次激活时间
+// 返回值：
+//   - time.Time: 实际到期时间
+func (l *License) TrialExpiry(startedAt time.Time) time.Time {
+	return TrialExpiry(startedAt, l.TrialDays, l.ExpiryDate)
+}
+
+// TrialExpiry 计算试用的实际到期时间（首次激活时间 + 试用天数，不晚于试用截止日期）
+// 参数：
+//   - startedAt: 首次激活时间
+//   - trialDays: 试用天数
+//   - deadline: 试用截止日期（许可证的 ExpiryDate）
+// 返回值：
+//   - time.Time: 实际到期时间
+func TrialExpiry(startedAt time.Time, trialDays int, deadline time.Time) time.Time {
+	expiry := startedAt.AddDate(0, 0, trialDays)
+	if expiry.After(deadline) {
+		return deadline
+	}
+	return expiry
 }
 
 // VerifyResult 验证结果
 type VerifyResult struct {
-	Valid        bool      // 是否有效
-	Expired      bool      // 是否过期
-	ExpiryDate   time.Time // 到期时间
-	DeviceID     string    // 设备ID
-	LicenseType  string    // 许可证类型
-	OfflineValid bool      // 离线验证结果（仅双重验证）
-	OnlineValid  bool      // 网络验证结果（仅双重验证和网络验证）
-	Message      string    // 验证消息
+	LicenseID        string       // 许可证ID（旧版许可证为空）
+	Valid            bool         // 是否有效
+	Expired          bool         // 是否过期（宽限期结束后为true）
+	InGracePeriod    bool         // 已过到期时间但仍在宽限期内（验证成功，应提醒用户续期）
+	ExpiresSoon      bool         // 即将到期（在到期前的提醒期内）
+	DaysRemaining    int          // 距到期时间的天数（不足一天为0，宽限期内为负数）
+	Revoked          bool         // 是否已被撤销
+	ExpiryDate       time.Time    // 到期时间（永久许可证为零值）
+	NotBefore        time.Time    // 生效时间（零值表示不限制）
+	Perpetual        bool         // 是否为永久许可证
+	MaintenanceUntil time.Time    // 维护截止日期（零值表示不限制，见 MaintenanceCovers）
+	DeviceID         string       // 设备ID
+	LicenseType      string       // 许可证类型
+	Features         []string     // 功能列表
+	Entitlements     Entitlements // 授权项（见 HasFeature、Limit、Value）
+	OfflineValid     bool         // 离线验证结果（仅双重验证）
+	OnlineValid      bool         // 网络验证结果（仅双重验证和网络验证）
+	Reason           string       // 验证失败的原因代码（见 Reason* 常量，成功时为空）
+	Message          string       // 验证消息
+}
+
+// 验证失败的原因代码
+// 服务器在 VerifyResult.Reason 中返回，用于区分离线与网络验证结果不一致的具体原因
+const (
+	// ReasonOfflineInvalid 离线许可证签名无效或无法解密
+	ReasonOfflineInvalid = "OFFLINE_INVALID"
+
+	// ReasonOfflineDeviceMismatch 离线许可证绑定的设备ID与请求不一致
+	ReasonOfflineDeviceMismatch = "OFFLINE_DEVICE_MISMATCH"
+
+	// ReasonOfflineExpired 离线许可证已过期
+	ReasonOfflineExpired = "OFFLINE_EXPIRED"
+
+	// ReasonOnlineExpired 服务器记录的许可证已过期
+	ReasonOnlineExpired = "ONLINE_EXPIRED"
+
+	// ReasonLicenseMismatch 离线许可证与服务器记录的许可证不一致
+	ReasonLicenseMismatch = "LICENSE_MISMATCH"
+
+	// ReasonLicenseRevoked 许可证已被服务器撤销
+	ReasonLicenseRevoked = "LICENSE_REVOKED"
+
+	// ReasonTrialNotStarted 设备尚未开始试用该试用许可证
+	ReasonTrialNotStarted = "TRIAL_NOT_STARTED"
+
+	// ReasonNotYetValid 许可证尚未到生效时间（NotBefore）
+	ReasonNotYetValid = "NOT_YET_VALID"
+)
+
+// MaintenanceCovers 判断维护期是否覆盖指定发布日期的版本
+// 未设置维护截止日期时总是覆盖；应用可以用自身的发布日期判断是否有权使用当前版本
+// 参数：
+//   - releaseDate: 当前版本的发布日期
+// 返回值：
+//   - bool: 发布日期不晚于维护截止日期时为true
+func (r *VerifyResult) MaintenanceCovers(releaseDate time.Time) bool {
+	return r.MaintenanceUntil.IsZero() || !releaseDate.After(r.MaintenanceUntil)
 }
 
 // Verifier 验证器接口
@@ -0,0 +1,151 @@
+// Package license 提供许可证生成和验证功能
+package license
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version 产品版本号（主版本.次版本.修订号）
+type version [3]int
+
+// parseVersion 解析版本号，例如 2、2.1、v2.1.3、2.1.3-beta（预发布和构建标识被忽略）
+func parseVersion(value string) (version, error) {
+	var v version
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if i := strings.IndexAny(value, "-+"); i >= 0 {
+		value = value[:i]
+	}
+
+	parts := strings.Split(value, ".")
+	if value == "" || len(parts) > 3 {
+		return v, fmt.Errorf("invalid version: %q", value)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version: %q", value)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// compare 比较版本号，返回 -1、0 或 1
+func (v version) compare(other version) int {
+	for i := range v {
+		switch {
+		case v[i] < other[i]:
+			return -1
+		case v[i] > other[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionConstraint 单个版本约束
+type versionConstraint struct {
+	op      string  // 比较运算符（>=、>、<=、<、=）
+	version version // 比较的版本号
+}
+
+// VersionRange 产品版本范围
+// 由空格分隔的约束组成，所有约束都满足时版本在范围内：
+//
+//	2.x、2.*、2     主版本为 2（>=2.0.0 <3.0.0）
+//	2.1.x、2.1      次版本为 2.1（>=2.1.0 <2.2.0）
+//	2.1.3           精确版本
+//	>=2.1 <4        比较运算符：>=、>、<=、<、=
+type VersionRange struct {
+	constraints []versionConstraint
+}
+
+// ParseVersionRange 解析版本范围
+// 参数：
+//   - value: 版本范围，例如 "2.x" 或 ">=2.1 <4"
+//
+// 返回值：
+//   - *VersionRange: 版本范围
+//   - error: 格式错误时的错误
+func ParseVersionRange(value string) (*VersionRange, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("version range is empty")
+	}
+
+	r := &VersionRange{}
+	for _, field := range fields {
+		constraints, err := parseConstraint(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", value, err)
+		}
+		r.constraints = append(r.constraints, constraints...)
+	}
+	return r, nil
+}
+
+// parseConstraint 解析单个约束（通配符形式展开为上下界两个约束）
+func parseConstraint(field string) ([]versionConstraint, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(field, op) {
+			v, err := parseVersion(field[len(op):])
+			if err != nil {
+				return nil, err
+			}
+			return []versionConstraint{{op: op, version: v}}, nil
+		}
+	}
+
+	// 通配符：2、2.x、2.*、2.1、2.1.x
+	value := strings.TrimPrefix(field, "v")
+	value = strings.TrimSuffix(strings.TrimSuffix(value, ".x"), ".*")
+	parts := len(strings.Split(value, "."))
+	v, err := parseVersion(value)
+	if err != nil {
+		return nil, err
+	}
+	if parts == 3 {
+		return []versionConstraint{{op: "=", version: v}}, nil
+	}
+
+	upper := v
+	upper[parts-1]++
+	return []versionConstraint{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+}
+
+// Contains 判断版本是否在范围内
+// 参数：
+//   - value: 产品版本，例如 2.3.1
+//
+// 返回值：
+//   - bool: 版本是否在范围内
+//   - error: 版本格式错误时的错误
+func (r *VersionRange) Contains(value string) (bool, error) {
+	v, err := parseVersion(value)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range r.constraints {
+		cmp := v.compare(c.version)
+		var ok bool
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
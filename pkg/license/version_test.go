@@ -0,0 +1,123 @@
+package license
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersionRange(t *testing.T) {
+	valid := []string{"2", "2.x", "2.*", "v2.x", "2.1", "2.1.x", "2.1.3", ">=2.1 <4", "=2.0.0", ">1 <=3.5"}
+	for _, value := range valid {
+		if _, err := ParseVersionRange(value); err != nil {
+			t.Fatalf("ParseVersionRange(%q): %v", value, err)
+		}
+	}
+
+	invalid := []string{"", "   ", "x", "2.x.y", "a.b", ">=", ">=abc", "2.1.3.4", "-1", "2..1", ">=2 <"}
+	for _, value := range invalid {
+		if _, err := ParseVersionRange(value); err == nil {
+			t.Fatalf("ParseVersionRange(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	tests := []struct {
+		versionRange string
+		version      string
+		want         bool
+	}{
+		{"2.x", "2.0.0", true},
+		{"2.x", "2.9.9", true},
+		{"2.x", "1.9.9", false},
+		{"2.x", "3.0.0", false},
+		{"2", "2.5", true},
+		{"2.*", "3", false},
+		{"2.1.x", "2.1.7", true},
+		{"2.1", "2.2.0", false},
+		{"2.1", "2.0.9", false},
+		{"2.1.3", "2.1.3", true},
+		{"2.1.3", "v2.1.3", true},
+		{"2.1.3", "2.1.3-beta", true},
+		{"2.1.3", "2.1.4", false},
+		{">=2.1 <4", "2.1.0", true},
+		{">=2.1 <4", "3.99", true},
+		{">=2.1 <4", "4.0.0", false},
+		{">=2.1 <4", "2.0.9", false},
+		{">2 <=3.5", "2.0.0", false},
+		{">2 <=3.5", "2.0.1", true},
+		{">2 <=3.5", "3.5.0", true},
+		{">2 <=3.5", "3.5.1", false},
+		{"=2.0", "2.0.0", true},
+		{"=2.0", "2.0.1", false},
+		{"10.x", "9.0.0", false},
+		{"10.x", "10.2.0", true},
+	}
+	for _, tc := range tests {
+		r, err := ParseVersionRange(tc.versionRange)
+		if err != nil {
+			t.Fatalf("ParseVersionRange(%q): %v", tc.versionRange, err)
+		}
+		got, err := r.Contains(tc.version)
+		if err != nil {
+			t.Fatalf("Contains(%q) in %q: %v", tc.version, tc.versionRange, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q contains %q = %v, want %v", tc.versionRange, tc.version, got, tc.want)
+		}
+	}
+
+	r, err := ParseVersionRange("2.x")
+	if err != nil {
+		t.Fatalf("ParseVersionRange: %v", err)
+	}
+	for _, value := range []string{"", "latest", "2.x", "1.2.3.4"} {
+		if _, err := r.Contains(value); err == nil {
+			t.Fatalf("Contains(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestCheckProduct(t *testing.T) {
+	tests := []struct {
+		name      string
+		productID string // 验证器所在的产品（为空表示未调用 SetProduct）
+		version   string
+		license   License
+		wantErr   bool
+	}{
+		{"no product set", "", "", License{ProductID: "globex"}, false},
+		{"same product", "acme", "2.3.1", License{ProductID: "acme"}, false},
+		{"other product", "acme", "2.3.1", License{ProductID: "globex"}, true},
+		{"unscoped license", "acme", "2.3.1", License{}, true},
+		{"version in range", "acme", "2.3.1", License{ProductID: "acme", VersionRange: "2.x"}, false},
+		{"version out of range", "acme", "3.0.0", License{ProductID: "acme", VersionRange: "2.x"}, true},
+		{"version unknown", "acme", "", License{ProductID: "acme", VersionRange: "2.x"}, true},
+		{"invalid range", "acme", "2.3.1", License{ProductID: "acme", VersionRange: ">=abc"}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &OfflineVerifier{}
+			if tc.productID != "" {
+				if err := v.SetProduct(tc.productID, tc.version); err != nil {
+					t.Fatalf("SetProduct: %v", err)
+				}
+			}
+			err := v.checkProduct(&tc.license)
+			if tc.wantErr && !errors.Is(err, ErrProductMismatch) {
+				t.Fatalf("checkProduct: err = %v, want ErrProductMismatch", err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("checkProduct: %v", err)
+			}
+		})
+	}
+
+	v := &OfflineVerifier{}
+	if err := v.SetProduct("", "1.0"); err == nil {
+		t.Fatal("SetProduct without product ID succeeded, want error")
+	}
+	if err := v.SetProduct("acme", "latest"); err == nil {
+		t.Fatal("SetProduct with invalid version succeeded, want error")
+	}
+}
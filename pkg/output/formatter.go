@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 // Format 输出格式类型
@@ -44,8 +47,54 @@ func NewTextFormatter() *TextFormatter {
 //   - string: 格式化后的文本
 //   - error: 格式化过程中的错误
 func (f *TextFormatter) Format(data interface{}) (string, error) {
-	// 简单的文本格式化
-	return fmt.Sprintf("%+v", data), nil
+	return formatText(reflect.ValueOf(data)), nil
+}
+
+// formatText 将数据格式化为人类可读的文本
+// 实现 fmt.Stringer 的类型使用 String()，映射按键名排序逐行输出，列表逐项输出，其余类型使用 %+v
+// 参数：
+//   - v: 要格式化的值
+// 返回值：
+//   - string: 格式化后的文本
+func formatText(v reflect.Value) string {
+	// 解引用指针和接口
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "<nil>"
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		lines := make([]string, 0, len(keys))
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("%s: %s", key.String(), formatText(v.MapIndex(key))))
+		}
+		return strings.Join(lines, "\n")
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		lines := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			lines = append(lines, formatText(v.Index(i)))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return fmt.Sprintf("%+v", v.Interface())
 }
 
 // Print 打印文本格式的数据
@@ -1,3 +1,5 @@
+//go:build ignore
+
 package main
 
 import (
@@ -5,7 +7,8 @@ import (
 	"os"
 	"time"
 
-	"github.com/Zeroshcat/LicenseManager/internal/crypto"
+	"github.com/Zeroshcat/LicenseManager/internal/database"
+	"github.com/Zeroshcat/LicenseManager/internal/keyring"
 	licensegen "github.com/Zeroshcat/LicenseManager/internal/license"
 	"github.com/Zeroshcat/LicenseManager/pkg/device"
 	"github.com/Zeroshcat/LicenseManager/pkg/license"
@@ -25,8 +28,8 @@ func main() {
 
 	// 2. 检查密钥文件是否存在
 	fmt.Println("2. 检查密钥文件...")
-	if _, err := os.Stat("private_key.pem"); os.IsNotExist(err) {
-		fmt.Println("❌ private_key.pem 不存在，请先运行 'licensemanager init'")
+	if _, err := os.Stat("license.db"); os.IsNotExist(err) {
+		fmt.Println("❌ license.db 不存在，请先运行 'licensemanager init'")
 		os.Exit(1)
 	}
 	if _, err := os.Stat("public_key.pem"); os.IsNotExist(err) {
@@ -41,9 +44,17 @@ func main() {
 
 	// 3. 加载密钥
 	fmt.Println("3. 加载密钥...")
-	privateKeyPEM, err := os.ReadFile("private_key.pem")
+	db, err := database.NewDB("license.db")
+	if err != nil {
+		fmt.Printf("❌ 打开数据库失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// 口令从环境变量 LICENSEMANAGER_PASSPHRASE 或终端读取
+	ring, err := keyring.Open(db, "")
 	if err != nil {
-		fmt.Printf("❌ 读取私钥失败: %v\n", err)
+		fmt.Printf("❌ 解锁密钥环失败: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -59,9 +70,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	privateKey, err := crypto.DecodePrivateKey(privateKeyPEM)
+	signer, _, err := ring.Signer()
 	if err != nil {
-		fmt.Printf("❌ 解码私钥失败: %v\n", err)
+		fmt.Printf("❌ 加载私钥失败: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -73,7 +84,7 @@ func main() {
 
 	// 4. 生成许可证
 	fmt.Println("4. 生成许可证...")
-	generator := licensegen.NewGenerator(privateKey, aesKey)
+	generator := licensegen.NewGenerator(signer, aesKey)
 	expiryDate := time.Now().Add(365 * 24 * time.Hour) // 1年后过期
 
 	licenseKey, err := generator.Generate(deviceID, license.LicenseTypeOffline, expiryDate, nil)